@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/manitest"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+// affectedOutputFormats are the supported formats for the affected command.
+var affectedOutputFormats = []string{render.OutputFormatText, render.OutputFormatJSON}
+
+type affectedCommand struct {
+	vmOptions       jsonnetvm.Options
+	entrypointGlobs []string
+	testGlobs       []string
+	outputFormat    string
+}
+
+type affectedReport struct {
+	Entrypoints []string `json:"entrypoints"`
+	Tests       []string `json:"tests"`
+}
+
+func (c *affectedCommand) RunE(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(affectedOutputFormats, c.outputFormat) {
+		return fmt.Errorf("unknown output format %q: %w", c.outputFormat, errCommandFailed)
+	}
+
+	cmd.SilenceUsage = true
+
+	changed := args
+	if len(changed) == 0 {
+		var err error
+
+		changed, err = readLines(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("failed to read changed files: %w: %w", err, errCommandFailed)
+		}
+	}
+
+	changed, err := absPaths(changed)
+	if err != nil {
+		return fmt.Errorf("%w: %w", err, errCommandFailed)
+	}
+
+	entrypoints, err := expandGlobs(c.entrypointGlobs)
+	if err != nil {
+		return err
+	}
+
+	tests, err := expandGlobs(c.testGlobs)
+	if err != nil {
+		return err
+	}
+
+	vmBuilder, err := c.vmOptions.NewBuilder(append(slices.Clone(entrypoints), tests...), cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	vm := vmBuilder.MakeVM()
+	cacheManager := manitest.NewCacheManager(vm, vmBuilder.TopLevelCallArgs())
+
+	report := affectedReport{
+		Entrypoints: affectedFiles(cmd.ErrOrStderr(), entrypoints, changed, func(entrypoint string) ([]string, error) {
+			return jsonnetvm.FindDependencies(vm, entrypoint)
+		}),
+		Tests: affectedFiles(cmd.ErrOrStderr(), tests, changed, func(testFile string) ([]string, error) {
+			deps, err := cacheManager.ListAllDependencies(testFile)
+			if err != nil {
+				return nil, err
+			}
+
+			return absPaths(deps)
+		}),
+	}
+
+	return writeAffectedReport(cmd.OutOrStdout(), report, c.outputFormat)
+}
+
+// affectedFiles returns the candidates which depend upon any of the changed files, which must be absolute.
+// Candidates whose dependencies cannot be determined, for example because they import a deleted file,
+// are reported as affected, with a warning, as the change may have broken them.
+func affectedFiles(warnings io.Writer, candidates []string, changed []string, findDependencies func(string) ([]string, error)) []string {
+	dependencies := dependencyGraph{}
+
+	var unknown []string
+
+	for _, candidate := range candidates {
+		deps, err := findDependencies(candidate)
+		if err != nil {
+			_, _ = fmt.Fprintf(warnings, "warning: unable to find dependencies, assuming %s is affected: %v\n", candidate, err)
+			unknown = append(unknown, candidate)
+
+			continue
+		}
+
+		dependencies[candidate] = deps
+	}
+
+	affected := append([]string{}, dependencies.affected(candidates, changed)...)
+	affected = append(affected, unknown...)
+	slices.Sort(affected)
+
+	return affected
+}
+
+func writeAffectedReport(w io.Writer, report affectedReport, outputFormat string) error {
+	if outputFormat == render.OutputFormatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		err := encoder.Encode(report)
+		if err != nil {
+			return fmt.Errorf("encode failed: %w: %w", err, errCommandFailed)
+		}
+
+		return nil
+	}
+
+	for _, f := range append(report.Entrypoints, report.Tests...) {
+		_, err := fmt.Fprintln(w, f)
+		if err != nil {
+			return fmt.Errorf("write failed: %w: %w", err, errCommandFailed)
+		}
+	}
+
+	return nil
+}
+
+// expandGlobs returns the files matching any of the glob patterns, which may include `**`, in sorted order.
+func expandGlobs(patterns []string) ([]string, error) {
+	files := []string{}
+
+	for _, pattern := range patterns {
+		matches, err := doublestar.FilepathGlob(pattern, doublestar.WithFilesOnly())
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w: %w", pattern, err, errCommandFailed)
+		}
+
+		files = append(files, matches...)
+	}
+
+	slices.Sort(files)
+
+	return slices.Compact(files), nil
+}
+
+// readLines returns the non-empty lines of r, with surrounding whitespace removed.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, filepath.FromSlash(line))
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+
+	return lines, nil
+}
+
+func NewAffectedCommand() *cobra.Command {
+	c := &affectedCommand{}
+
+	command := &cobra.Command{
+		Use:   "affected [flags] [changed file]...",
+		Short: "List the entrypoints and test files affected by changed files",
+		Long: "List the entrypoints and test files which depend upon any of the changed files, given as arguments " +
+			"or, when there are no arguments, one per line on stdin, for example from `git diff --name-only`. " +
+			"Dependencies include imported files and, for test files, fixtures. " +
+			"Affected entrypoints are listed before affected test files, one per line, so that the output of " +
+			"`affected --tests` can be passed to `test` using `xargs`.",
+		RunE: c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().StringArrayVarP(
+		&c.entrypointGlobs, "entrypoints", "e", nil,
+		"Glob matching candidate entrypoints, which may include **. Can be specified multiple times",
+	)
+	_ = command.MarkPersistentFlagFilename("entrypoints", "jsonnet")
+	command.PersistentFlags().StringArrayVarP(
+		&c.testGlobs, "tests", "t", nil,
+		"Glob matching candidate test files, which may include **. Can be specified multiple times",
+	)
+	_ = command.MarkPersistentFlagFilename("tests", "jsonnet")
+	command.PersistentFlags().StringVarP(
+		&c.outputFormat, "output-format", "", render.OutputFormatText,
+		fmt.Sprintf("Format used to list affected files on stdout, one of: %s", strings.Join(affectedOutputFormats, ", ")),
+	)
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewAffectedCommand())
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffected(t *testing.T) {
+	t.Parallel()
+
+	candidates := []string{
+		"-J", "../examples/test_lib",
+		"--entrypoints", "../examples/*.jsonnet",
+		"--tests", "../examples/tests/test[12].manitest.jsonnet",
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantOutput string
+	}{
+		{
+			name:       "library",
+			args:       []string{"../examples/test_lib/library.libsonnet"},
+			wantOutput: "../examples/render.jsonnet\n../examples/yaml.jsonnet\n",
+		},
+		{
+			name:       "fixture",
+			args:       []string{"../examples/tests/fixtures/test1.testcase3.txt"},
+			wantOutput: "../examples/tests/test1.manitest.jsonnet\n",
+		},
+		{
+			name:       "entrypoint",
+			args:       []string{"../examples/render.jsonnet"},
+			wantOutput: "../examples/render.jsonnet\n",
+		},
+		{
+			name:       "stdin",
+			stdin:      "../examples/tests/test2.manitest.jsonnet\n\n../examples/yaml.jsonnet\n",
+			wantOutput: "../examples/yaml.jsonnet\n../examples/tests/test2.manitest.jsonnet\n",
+		},
+		{
+			name: "unrelated",
+			args: []string{"../README.md"},
+		},
+		{
+			name:       "json",
+			args:       []string{"--output-format", "json", "../examples/test_lib/library.libsonnet"},
+			wantOutput: "{\n  \"entrypoints\": [\n    \"../examples/render.jsonnet\",\n    \"../examples/yaml.jsonnet\"\n  ],\n  \"tests\": []\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			command := NewAffectedCommand()
+			command.SetIn(strings.NewReader(tt.stdin))
+
+			output, err := executeCommand(command, append(tt.args, candidates...))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutput, output)
+		})
+	}
+}
+
+// TestAffectedUnknownDependencies verifies that candidates whose dependencies cannot be found are assumed to be affected.
+func TestAffectedUnknownDependencies(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	entrypoint := filepath.Join(dir, "entrypoint.jsonnet")
+	require.NoError(t, os.WriteFile(entrypoint, []byte(`import 'deleted.libsonnet'`), 0644))
+
+	output, err := executeCommand(NewAffectedCommand(), []string{
+		"--entrypoints", filepath.Join(dir, "*.jsonnet"), filepath.Join(dir, "deleted.libsonnet"),
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "warning: unable to find dependencies, assuming "+entrypoint+" is affected")
+	assert.True(t, strings.HasSuffix(output, entrypoint+"\n"))
+}
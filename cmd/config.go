@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+type configCommand struct{}
+
+func (c *configCommand) RunE(cmd *cobra.Command, args []string) error {
+	cfg, err := loadProjectConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg == nil {
+		_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "no configuration file found")
+		return nil
+	}
+
+	flags := commandFlags()
+
+	names := args
+	if len(names) == 0 {
+		for _, c := range rootCmd.Commands() {
+			names = append(names, c.Name())
+		}
+	}
+
+	effective := map[string]map[string]interface{}{}
+
+	for _, name := range names {
+		commandFlags, ok := flags[name]
+		if !ok {
+			return fmt.Errorf("unknown command %q: %w", name, errCommandFailed)
+		}
+
+		options := cfg.Options(name, commandFlags)
+		if len(options) > 0 {
+			effective[name] = options
+		}
+	}
+
+	out, err := yaml.Marshal(effective)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w: %w", err, errCommandFailed)
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "# %s\n%s", cfg.Path, out)
+
+	return nil
+}
+
+func NewConfigCommand() *cobra.Command {
+	c := &configCommand{}
+
+	return &cobra.Command{
+		Use:   "config [command]...",
+		Short: "Print the effective project configuration for each command",
+		Long: "Print the options from the project configuration file which apply to each command, " +
+			"merging the defaults section with the command's own section. Paths are resolved relative to the configuration file. " +
+			"Options given on the command line take precedence over the configuration.",
+		RunE: c.RunE,
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(NewConfigCommand())
+}
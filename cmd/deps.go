@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/deps"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+)
+
+type depsCommand struct {
+	vmOptions    jsonnetvm.Options
+	outputFormat string
+}
+
+func (c *depsCommand) RunE(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(deps.OutputFormats, c.outputFormat) {
+		return fmt.Errorf("unknown output format %q: %w", c.outputFormat, errCommandFailed)
+	}
+
+	cmd.SilenceUsage = true
+
+	vmBuilder, err := c.vmOptions.NewBuilder(args, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	graph := deps.Build(args, vmBuilder.Importer(), vmBuilder.JPaths())
+
+	err = deps.Write(cmd.OutOrStdout(), graph, c.outputFormat)
+	if err != nil {
+		return fmt.Errorf("failed to write dependencies: %w: %w", err, errCommandFailed)
+	}
+
+	return nil
+}
+
+func NewDepsCommand() *cobra.Command {
+	c := &depsCommand{}
+
+	command := &cobra.Command{
+		Use:   "deps [flags] <file>...",
+		Short: "Print the graph of files imported by Jsonnet files",
+		Long: "Print the graph of files imported by Jsonnet files, following import, importstr and importbin. " +
+			"Import cycles and imports which cannot be resolved are flagged, along with the directories searched.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().StringVarP(
+		&c.outputFormat, "output-format", "", deps.OutputFormatTree,
+		fmt.Sprintf("Format of the graph, one of: %s", strings.Join(deps.OutputFormats, ", ")),
+	)
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewDepsCommand())
+}
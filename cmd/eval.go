@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+const (
+	evalOutputJSON = "json"
+	evalOutputYAML = "yaml"
+	evalOutputRaw  = "raw"
+
+	// evalSnippetName is the file name given to code evaluated with --exec, as used by jsonnet.
+	evalSnippetName = "<cmdline>"
+)
+
+var evalOutputFormats = []string{evalOutputJSON, evalOutputYAML, evalOutputRaw}
+
+// evalPathSnippet evaluates a file or code, applying any top-level arguments, and then selects
+// a path within the result, so that only the selected value is evaluated.
+const evalPathSnippet = `
+	local value = %s;
+	local result = if std.isFunction(value) then value(%s) else value;
+	result%s
+`
+
+type evalCommand struct {
+	vmOptions     jsonnetvm.Options
+	renderOptions render.Options
+	exec          bool
+	outputFormat  string
+	path          string
+}
+
+func (c *evalCommand) RunE(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(evalOutputFormats, c.outputFormat) {
+		return fmt.Errorf("unknown output format %q: %w", c.outputFormat, errCommandFailed)
+	}
+
+	err := validateYAMLFlags(c.renderOptions)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	var entrypoints []string
+	if !c.exec {
+		entrypoints = args
+	}
+
+	vmBuilder, err := c.vmOptions.NewBuilder(entrypoints, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	output, err := c.evaluate(vmBuilder, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to evaluate jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	content, err := c.format(output)
+	if err != nil {
+		return err
+	}
+
+	_, err = cmd.OutOrStdout().Write(content)
+	if err != nil {
+		return fmt.Errorf("write failed: %w: %w", err, errCommandFailed)
+	}
+
+	return nil
+}
+
+// evaluate returns the JSON output of the file or, with --exec, code given in arg.
+func (c *evalCommand) evaluate(vmBuilder *jsonnetvm.Builder, arg string) (string, error) {
+	vm := vmBuilder.MakeVM()
+
+	if c.path == "" {
+		if c.exec {
+			return vm.EvaluateAnonymousSnippet(evalSnippetName, arg)
+		}
+
+		return vm.EvaluateFile(arg)
+	}
+
+	value := "(\n" + arg + "\n)"
+
+	if !c.exec {
+		// The path is made absolute, so that it is not resolved using the library search path
+		absFileName, err := filepath.Abs(arg)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", arg, err)
+		}
+
+		value = jsonnetvm.ImportCode(absFileName)
+	}
+
+	path := c.path
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+
+	return vm.EvaluateAnonymousSnippet(evalSnippetName, fmt.Sprintf(evalPathSnippet, value, vmBuilder.TopLevelCallArgs(), path))
+}
+
+// format converts the JSON output of the VM to the output format.
+func (c *evalCommand) format(output string) ([]byte, error) {
+	switch c.outputFormat {
+	case evalOutputRaw:
+		var s string
+
+		err := json.Unmarshal([]byte(output), &s)
+		if err != nil {
+			return nil, fmt.Errorf("raw output requires a string result: %w", errCommandFailed)
+		}
+
+		return []byte(s), nil
+	default:
+		content, err := render.FormatValue(output, c.outputFormat, c.renderOptions)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", err, errCommandFailed)
+		}
+
+		return content, nil
+	}
+}
+
+func NewEvalCommand() *cobra.Command {
+	c := &evalCommand{}
+
+	command := &cobra.Command{
+		Use:   "eval [flags] <file | -e code>",
+		Short: "Evaluate a Jsonnet file or expression",
+		Long: "Evaluate a Jsonnet file, or code with --exec, using the same natives, library search path and " +
+			"external variables as the other commands, and print the result.",
+		Args: cobra.ExactArgs(1),
+		RunE: c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().BoolVarP(
+		&c.exec, "exec", "e", false,
+		"Treat the argument as Jsonnet code, rather than a file name",
+	)
+	command.PersistentFlags().StringVarP(
+		&c.outputFormat, "output-format", "o", evalOutputJSON,
+		fmt.Sprintf("Format of the result, one of: %s. raw requires the result to be a string", strings.Join(evalOutputFormats, ", ")),
+	)
+	command.PersistentFlags().StringVarP(
+		&c.path, "path", "", "",
+		"Only evaluate and print the value at a path within the result, using Jsonnet syntax, such as spec.containers[0]",
+	)
+	addYAMLFlags(command.PersistentFlags(), &c.renderOptions)
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewEvalCommand())
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name:       "file",
+			args:       []string{"-J", "../examples/test_lib", "../examples/render.jsonnet", "--path", "['file.json'].foo"},
+			wantOutput: "{\n  \"a\": true,\n  \"b\": 2\n}\n",
+		},
+		{
+			name:       "natives",
+			args:       []string{"-e", "std.native('semverParse')('1.2.3').minor"},
+			wantOutput: "2\n",
+		},
+		{
+			name:       "yaml_priority_keys",
+			args:       []string{"-e", "{ items: [{ b: 1, name: 'x' }] }", "--output-format", "yaml", "-P", "name"},
+			wantOutput: "items:\n- name: x\n  b: 1\n",
+		},
+		{
+			name:       "raw",
+			args:       []string{"-e", "'line\\n'", "--output-format", "raw"},
+			wantOutput: "line\n",
+		},
+		{
+			name:    "raw_not_string",
+			args:    []string{"-e", "{}", "--output-format", "raw"},
+			wantErr: true,
+		},
+		{
+			name:       "path_only_evaluates_selection",
+			args:       []string{"-e", "{ a: error 'unused', b: { c: [1, 2] } }", "--path", "b.c[1]"},
+			wantOutput: "2\n",
+		},
+		{
+			name:       "path_with_top_level_arguments",
+			args:       []string{"-e", "function(x) { a: x }", "--tla-code", "x=1 + 1", "--path", ".a"},
+			wantOutput: "2\n",
+		},
+		{
+			name:       "top_level_arguments",
+			args:       []string{"-e", "function(x) x", "--tla-str", "x=hi", "--output-format", "raw"},
+			wantOutput: "hi",
+		},
+		{
+			name:    "error",
+			args:    []string{"-e", "error 'boom'"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := executeCommand(NewEvalCommand(), tt.args)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutput, output)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+// evaluateFunc evaluates a single entrypoint, returning the rendered files.
+type evaluateFunc func(vm *jsonnet.VM, entrypoint string) ([]*render.File, error)
+
+// entrypointEvaluator evaluates several entrypoints concurrently, using a bounded pool of workers.
+type entrypointEvaluator struct {
+	jobs int
+}
+
+func (e *entrypointEvaluator) addFlags(flags *pflag.FlagSet) {
+	flags.IntVarP(
+		&e.jobs, "jobs", "j", runtime.NumCPU(),
+		"Number of entrypoints to evaluate concurrently",
+	)
+}
+
+// evaluateEntrypoints evaluates every entrypoint, returning the rendered files from all entrypoints
+// in argument order. Each worker builds a single VM using makeVM and reuses it for every entrypoint it
+// evaluates, so that files imported by several entrypoints are only parsed once per worker.
+// Fails if two entrypoints generate the same output path.
+func (e *entrypointEvaluator) evaluateEntrypoints(entrypoints []string, makeVM func() *jsonnet.VM, evaluate evaluateFunc) ([]*render.File, error) {
+	jobs := min(max(e.jobs, 1), len(entrypoints))
+
+	results := make([][]*render.File, len(entrypoints))
+	errs := make([]error, len(entrypoints))
+
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range jobs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			vm := makeVM()
+
+			for i := range indexes {
+				results[i], errs[i] = evaluate(vm, entrypoints[i])
+				if errs[i] != nil {
+					errs[i] = fmt.Errorf("%s: %w", entrypoints[i], errs[i])
+				}
+			}
+		}()
+	}
+
+	for i := range entrypoints {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*render.File
+	for _, result := range results {
+		files = append(files, result...)
+	}
+
+	err = render.CheckDuplicatePaths(files)
+	if err != nil {
+		return nil, fmt.Errorf("conflicting outputs: %w: %w", err, errCommandFailed)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/kr/text"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/diff"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/format"
+)
+
+type fmtCommand struct {
+	formatOptions format.Options
+	inPlace       bool
+	check         bool
+}
+
+func (c *fmtCommand) RunE(cmd *cobra.Command, args []string) error {
+	formatter, err := c.formatOptions.NewFormatter()
+	if err != nil {
+		return fmt.Errorf("failed to configure formatter: %w: %w", err, errCommandFailed)
+	}
+
+	cmd.SilenceUsage = true
+
+	files, err := format.FindFiles(args)
+	if err != nil {
+		return fmt.Errorf("failed to find files: %w: %w", err, errCommandFailed)
+	}
+
+	unformatted := 0
+	invalid := 0
+
+	for _, fileName := range files {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w: %w", err, errCommandFailed)
+		}
+
+		current := string(content)
+
+		formatted, err := formatter.Format(fileName, current)
+		if err != nil {
+			// Continue, so that every invalid file is reported
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s %v\n", color.HiRedString("invalid"), err)
+			invalid = invalid + 1
+
+			continue
+		}
+
+		switch {
+		case c.check:
+			if formatted != current {
+				unformatted = unformatted + 1
+
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", color.HiYellowString("unformatted"), fileName)
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n", text.Indent(diff.Pretty(fileName, current, formatted), "    "))
+			}
+		case c.inPlace:
+			if formatted != current {
+				err = os.WriteFile(fileName, []byte(formatted), 0644)
+				if err != nil {
+					return fmt.Errorf("failed to write file: %w: %w", err, errCommandFailed)
+				}
+
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), fileName)
+			}
+		default:
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), formatted)
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("files could not be formatted: %d: %w", invalid, exitcode.Invalid())
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("files not formatted: %d: %w", unformatted, exitcode.Outdated())
+	}
+
+	return nil
+}
+
+func NewFmtCommand() *cobra.Command {
+	c := &fmtCommand{}
+
+	command := &cobra.Command{
+		Use:   "fmt [flags] <file or directory>...",
+		Short: "Format Jsonnet files",
+		Long: "Format Jsonnet files using the go-jsonnet formatter. Directories are searched recursively for " +
+			".jsonnet and .libsonnet files, skipping hidden and vendor directories. " +
+			"By default, the formatted files are written to stdout.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: c.RunE,
+	}
+
+	c.formatOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().BoolVarP(
+		&c.inPlace, "in-place", "i", false,
+		"Rewrite files which are not formatted, listing them on stdout",
+	)
+	command.PersistentFlags().BoolVarP(
+		&c.check, "check", "", false,
+		"Do not write files, instead fail if any file is not formatted, showing a diff",
+	)
+	command.MarkFlagsMutuallyExclusive("in-place", "check")
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewFmtCommand())
+}
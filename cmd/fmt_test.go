@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+)
+
+func TestFmt(t *testing.T) {
+	t.Parallel()
+
+	const formatted = "{ a: 'b' }\n"
+
+	const unformatted = `{"a":"b"}`
+
+	tests := []struct {
+		name       string
+		args       []string
+		source     string
+		exitCode   int
+		wantOutput string
+		wantSource string
+	}{
+		{
+			name:       "stdout",
+			source:     unformatted,
+			wantOutput: formatted,
+			wantSource: unformatted,
+		},
+		{
+			name:       "in_place",
+			args:       []string{"--in-place"},
+			source:     unformatted,
+			wantOutput: "FILE\n",
+			wantSource: formatted,
+		},
+		{
+			name:       "in_place_formatted",
+			args:       []string{"--in-place"},
+			source:     formatted,
+			wantSource: formatted,
+		},
+		{
+			name:       "check",
+			args:       []string{"--check"},
+			source:     unformatted,
+			exitCode:   4,
+			wantOutput: "unformatted FILE\n",
+			wantSource: unformatted,
+		},
+		{
+			name:       "check_formatted",
+			args:       []string{"--check"},
+			source:     formatted,
+			wantSource: formatted,
+		},
+		{
+			name:       "invalid",
+			args:       []string{"--check"},
+			source:     "{",
+			exitCode:   3,
+			wantOutput: "invalid FILE:1:2",
+			wantSource: "{",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			fileName := filepath.Join(dir, "sub", "file.jsonnet")
+			require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0755))
+			require.NoError(t, os.WriteFile(fileName, []byte(tt.source), 0644))
+
+			output, err := executeCommand(NewFmtCommand(), append(tt.args, dir))
+
+			if tt.exitCode == 0 {
+				require.NoError(t, err)
+			} else {
+				var errWithExitCode *exitcode.Error
+				require.True(t, errors.As(err, &errWithExitCode))
+				assert.EqualValues(t, tt.exitCode, errWithExitCode.ExitCode)
+			}
+
+			if tt.wantOutput == "" {
+				assert.Empty(t, output)
+			} else {
+				assert.Contains(t, output, strings.ReplaceAll(tt.wantOutput, "FILE", fileName))
+			}
+
+			content, err := os.ReadFile(fileName)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSource, string(content))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/format"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/lint"
+)
+
+type lintCommand struct {
+	vmOptions    jsonnetvm.Options
+	outputFormat string
+}
+
+func (c *lintCommand) RunE(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(lint.OutputFormats, c.outputFormat) {
+		return fmt.Errorf("unknown output format %q: %w", c.outputFormat, errCommandFailed)
+	}
+
+	cmd.SilenceUsage = true
+
+	files, err := format.FindFiles(args)
+	if err != nil {
+		return fmt.Errorf("failed to find files: %w: %w", err, errCommandFailed)
+	}
+
+	vmBuilder, err := c.vmOptions.NewBuilder(files, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	findings, err := lint.Lint(vmBuilder.MakeVM(), files)
+	if err != nil {
+		return fmt.Errorf("failed to lint: %w: %w", err, errCommandFailed)
+	}
+
+	err = lint.Write(cmd.OutOrStdout(), findings, c.outputFormat, toolVersion())
+	if err != nil {
+		return fmt.Errorf("failed to write findings: %w: %w", err, errCommandFailed)
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("lint findings: %d: %w", len(findings), exitcode.Failed())
+	}
+
+	return nil
+}
+
+func NewLintCommand() *cobra.Command {
+	c := &lintCommand{}
+
+	command := &cobra.Command{
+		Use:   "lint [flags] <file or directory>...",
+		Short: "Find problems in Jsonnet files",
+		Long: "Find problems in Jsonnet files, such as unused and shadowed variables, using the go-jsonnet linter. " +
+			"Directories are searched recursively for .jsonnet and .libsonnet files, skipping hidden and vendor directories. " +
+			"Findings can be suppressed with a `// jsonnet-tool:lint-ignore [rule]...` comment on the same or the preceding line, " +
+			"or a `// jsonnet-tool:lint-ignore-file [rule]...` comment anywhere in the file.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().StringVarP(
+		&c.outputFormat, "output-format", "", lint.OutputFormatText,
+		fmt.Sprintf("Format used to list findings on stdout, one of: %s", strings.Join(lint.OutputFormats, ", ")),
+	)
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewLintCommand())
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+)
+
+// TestLint verifies that directories are linted using the library search path.
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	sources := map[string]string{
+		"lib/lib.libsonnet":         `{ value: 1 }`,
+		"src/clean.jsonnet":         `local lib = import 'lib.libsonnet'; { value: lib.value }`,
+		"src/nested/unused.jsonnet": "local lib = import 'lib.libsonnet';\n{}",
+	}
+
+	for name, source := range sources {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(source), 0644))
+	}
+
+	output, err := executeCommand(NewLintCommand(), []string{"-J", filepath.Join(dir, "lib"), filepath.Join(dir, "src")})
+
+	var errWithExitCode *exitcode.Error
+	require.True(t, errors.As(err, &errWithExitCode))
+	assert.EqualValues(t, 1, errWithExitCode.ExitCode)
+	assert.Contains(t, output, filepath.Join(dir, "src", "nested", "unused.jsonnet")+":1:7: Unused variable: lib (unused-variable)\n")
+	assert.NotContains(t, output, "clean.jsonnet")
+
+	_, err = executeCommand(NewLintCommand(), []string{"-J", filepath.Join(dir, "lib"), filepath.Join(dir, "src", "clean.jsonnet")})
+	require.NoError(t, err)
+}
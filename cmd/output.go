@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/config"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/schema"
+)
+
+// outputFlags control how rendered files are emitted by the render and yaml commands.
+type outputFlags struct {
+	check        bool
+	prune        bool
+	dryRun       bool
+	outputFormat string
+	depfile      string
+	schemas      []string
+
+	// validator validates files against the schemas, once compiled by validate
+	validator *schema.Validator
+}
+
+func (o *outputFlags) addFlags(flags *pflag.FlagSet) {
+	flags.BoolVarP(
+		&o.check, "check", "", false,
+		"Do not write files, instead fail if generated files are missing, changed or extra",
+	)
+	flags.BoolVarP(
+		&o.prune, "prune", "", false,
+		"Delete files previously generated by the entrypoint which are no longer emitted. "+
+			"Concurrent invocations must not share a --multi directory, as each replaces the manifest of generated files",
+	)
+	flags.BoolVarP(
+		&o.dryRun, "dry-run", "", false,
+		"With --prune, list the files which would be deleted without writing or deleting any files",
+	)
+	flags.StringVarP(
+		&o.outputFormat, "output-format", "", render.OutputFormatText,
+		fmt.Sprintf("Format used to list generated files on stdout, one of: %s", strings.Join(render.OutputFormats, ", ")),
+	)
+	flags.StringVarP(
+		&o.depfile, "depfile", "", "",
+		"Write a make dependency file, listing the files generated by each entrypoint and the files they import",
+	)
+	_ = flags.SetAnnotation("depfile", cobra.BashCompFilenameExt, []string{"d"})
+	flags.StringArrayVarP(
+		&o.schemas, "schema", "", nil,
+		"Validate files whose paths, relative to the output directory, match a glob against a JSON Schema, given as glob=schema.json. Can be repeated",
+	)
+	_ = flags.SetAnnotation("schema", cobra.BashCompFilenameExt, []string{"json"})
+	_ = flags.SetAnnotation("schema", config.KeyValueAnnotation, []string{"true"})
+}
+
+// addYAMLFlags adds the flags controlling the ordering and formatting of YAML output.
+func addYAMLFlags(flags *pflag.FlagSet, options *render.Options) {
+	flags.StringArrayVarP(
+		&options.PriorityKeys, "priority-keys", "P", nil,
+		"Order these keys first in YAML output",
+	)
+	flags.IntVarP(
+		&options.YAML.Indent, "yaml-indent", "", 2,
+		"Number of spaces used for indentation in YAML output",
+	)
+	flags.IntVarP(
+		&options.YAML.LineWidth, "yaml-line-width", "", 80,
+		"Preferred line width in YAML output, beyond which long strings are folded. Use -1 for unlimited",
+	)
+	flags.BoolVarP(
+		&options.YAML.LiteralBlocks, "yaml-literal-blocks", "", false,
+		"Emit multiline strings as literal block scalars in YAML output, even when a quote style is set",
+	)
+	flags.StringVarP(
+		&options.YAML.QuoteStyle, "yaml-quote-style", "", "",
+		"Quoting style for string values in YAML output, one of: single, double. By default, strings are only quoted when required",
+	)
+}
+
+// addJSONFlags adds the flags controlling the formatting of JSON output.
+func addJSONFlags(flags *pflag.FlagSet, options *render.Options) {
+	flags.IntVarP(
+		&options.JSON.Indent, "json-indent", "", 2,
+		"Number of spaces used for indentation in JSON output, at least 1. Use --json-compact to omit whitespace",
+	)
+	flags.BoolVarP(
+		&options.JSON.Compact, "json-compact", "", false,
+		"Emit JSON output without indentation or whitespace",
+	)
+	flags.BoolVarP(
+		&options.JSON.TrailingNewline, "json-trailing-newline", "", false,
+		"End JSON output with a newline",
+	)
+	flags.BoolVarP(
+		&options.JSON.NoHTMLEscape, "json-no-html-escape", "", false,
+		"Write <, > and & within strings in JSON output as-is, rather than as \\u escapes",
+	)
+	flags.BoolVarP(
+		&options.JSON.PreserveNumbers, "json-preserve-numbers", "", false,
+		"Write fractional numbers in JSON output as formatted by Jsonnet, rather than reformatting them. Integers are always written as formatted by Jsonnet",
+	)
+}
+
+// validateYAMLFlags checks the flags added by addYAMLFlags before any entrypoints are evaluated.
+func validateYAMLFlags(options render.Options) error {
+	if options.YAML.Indent < 1 {
+		return fmt.Errorf("invalid --yaml-indent %d, must be at least 1: %w", options.YAML.Indent, errCommandFailed)
+	}
+
+	if options.YAML.QuoteStyle != "" && !slices.Contains(render.YAMLQuoteStyles, options.YAML.QuoteStyle) {
+		return fmt.Errorf(
+			"unknown YAML quote style %q, expected one of: %s: %w",
+			options.YAML.QuoteStyle, strings.Join(render.YAMLQuoteStyles, ", "), errCommandFailed,
+		)
+	}
+
+	return nil
+}
+
+// validateJSONFlags checks the flags added by addJSONFlags before any entrypoints are evaluated.
+func validateJSONFlags(options render.Options) error {
+	if options.JSON.Indent < 1 {
+		return fmt.Errorf("invalid --json-indent %d, must be at least 1, use --json-compact to omit whitespace: %w", options.JSON.Indent, errCommandFailed)
+	}
+
+	return nil
+}
+
+// validate checks the flags, and the render options they share with addYAMLFlags, before any
+// entrypoints are evaluated. Any schemas are compiled once, for every render.
+func (o *outputFlags) validate(options render.Options) error {
+	if !slices.Contains(render.OutputFormats, o.outputFormat) {
+		return fmt.Errorf("unknown output format %q: %w", o.outputFormat, errCommandFailed)
+	}
+
+	if o.dryRun && !o.prune {
+		return fmt.Errorf("--dry-run can only be used with --prune: %w", errCommandFailed)
+	}
+
+	if o.check && o.prune {
+		return fmt.Errorf("--check cannot be used with --prune: %w", errCommandFailed)
+	}
+
+	err := validateYAMLFlags(options)
+	if err != nil {
+		return err
+	}
+
+	if len(o.schemas) == 0 {
+		return nil
+	}
+
+	mappings, err := schema.ParseMappings(o.schemas)
+	if err != nil {
+		return fmt.Errorf("invalid --schema: %w: %w", err, errCommandFailed)
+	}
+
+	o.validator, err = schema.New(mappings)
+	if err != nil {
+		return fmt.Errorf("failed to load schemas: %w: %w", err, errCommandFailed)
+	}
+
+	return nil
+}
+
+// validateSchemas validates files against the schemas mapped to their paths, before any headers are
+// applied. Each violation is reported to stderr. Returns an exitcode.SchemaViolation error if any
+// file does not conform to its schemas.
+func (o *outputFlags) validateSchemas(cmd *cobra.Command, files []*render.File, options render.Options) error {
+	if o.validator == nil {
+		return nil
+	}
+
+	sorted := slices.Clone(files)
+	slices.SortFunc(sorted, func(a, b *render.File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	violations := 0
+
+	for _, file := range sorted {
+		name, err := filepath.Rel(options.MultiDir, file.Path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w: %w", file.Path, err, errCommandFailed)
+		}
+
+		fileViolations, err := o.validator.Validate(name, file.Path, file.Format, file.Content)
+		if err != nil {
+			return fmt.Errorf("schema validation failed: %w: %w", err, errCommandFailed)
+		}
+
+		for _, violation := range fileViolations {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s %s\n", color.HiRedString("invalid"), violation)
+		}
+
+		violations += len(fileViolations)
+	}
+
+	if violations > 0 {
+		return fmt.Errorf("schema violations: %d: %w", violations, exitcode.SchemaViolation())
+	}
+
+	return nil
+}
+
+// emitFiles validates the rendered files against any schemas, then will either write them to
+// disk and list them or, in check mode, compare them against the files already on disk.
+// makeVM is used to find the dependencies of each entrypoint for the depfile.
+func (o *outputFlags) emitFiles(cmd *cobra.Command, files []*render.File, options render.Options, makeVM func() *jsonnet.VM) error {
+	err := o.validateSchemas(cmd, files, options)
+	if err != nil {
+		return err
+	}
+
+	files, err = render.ApplyHeaders(files, options, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to apply headers: %w: %w", err, errCommandFailed)
+	}
+
+	if o.check {
+		err := render.CheckFiles(files, options, cmd.OutOrStdout())
+		if err != nil {
+			return fmt.Errorf("check failed: %w", err)
+		}
+
+		return nil
+	}
+
+	written, err := o.writeFiles(cmd, files, options)
+	if err != nil || !written {
+		return err
+	}
+
+	err = o.writeDepfile(files, makeVM)
+	if err != nil {
+		return err
+	}
+
+	err = render.WriteReport(cmd.OutOrStdout(), files, o.outputFormat)
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w: %w", err, errCommandFailed)
+	}
+
+	return nil
+}
+
+// writeFiles writes files, which already include any headers, to disk, pruning stale files and
+// recording the files in the manifest. Returns false if no files were written, as for a dry run.
+func (o *outputFlags) writeFiles(cmd *cobra.Command, files []*render.File, options render.Options) (bool, error) {
+	manifest, err := render.LoadManifest(options.MultiDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to load manifest: %w: %w", err, errCommandFailed)
+	}
+
+	stale, err := manifest.Stale(files)
+	if err != nil {
+		return false, fmt.Errorf("failed to find stale files: %w: %w", err, errCommandFailed)
+	}
+
+	if o.prune && o.dryRun {
+		for _, filePath := range stale {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), filePath)
+		}
+
+		return false, nil
+	}
+
+	err = render.WriteFiles(files, options)
+	if err != nil {
+		return false, fmt.Errorf("failed to write files: %w: %w", err, errCommandFailed)
+	}
+
+	if o.prune {
+		err = render.PruneFiles(stale, options, cmd.ErrOrStderr())
+		if err != nil {
+			return false, fmt.Errorf("failed to prune files: %w: %w", err, errCommandFailed)
+		}
+
+		stale = nil
+	}
+
+	err = manifest.Record(files, stale)
+	if err != nil {
+		return false, fmt.Errorf("failed to record manifest: %w: %w", err, errCommandFailed)
+	}
+
+	err = manifest.Save()
+	if err != nil {
+		return false, fmt.Errorf("failed to save manifest: %w: %w", err, errCommandFailed)
+	}
+
+	return true, nil
+}
+
+// writeDepfile writes the depfile, if one was requested, with a rule for each entrypoint.
+func (o *outputFlags) writeDepfile(files []*render.File, makeVM func() *jsonnet.VM) error {
+	if o.depfile == "" {
+		return nil
+	}
+
+	outputs := map[string][]string{}
+	for _, file := range files {
+		outputs[file.Source] = append(outputs[file.Source], file.Path)
+	}
+
+	vm := makeVM()
+	dependencies := dependencyGraph{}
+
+	for entrypoint := range outputs {
+		deps, err := jsonnetvm.FindDependencies(vm, entrypoint)
+		if err != nil {
+			return fmt.Errorf("failed to find dependencies: %w: %w", err, errCommandFailed)
+		}
+
+		dependencies[entrypoint] = deps
+	}
+
+	err := render.WriteDepfile(o.depfile, outputs, dependencies)
+	if err != nil {
+		return fmt.Errorf("failed to write depfile: %w: %w", err, errCommandFailed)
+	}
+
+	return nil
+}
@@ -1,112 +1,194 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"path"
+	"strings"
 
-	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/pkg/natives"
-
-	"github.com/fatih/color"
 	jsonnet "github.com/google/go-jsonnet"
 	"github.com/spf13/cobra"
 
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
 	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
 )
 
-var renderCommandJPaths []string
-var renderCommandRenderOptions render.Options
-
-func init() {
-	rootCmd.AddCommand(renderCommand)
-	renderCommand.PersistentFlags().StringArrayVarP(
-		&renderCommandJPaths, "jpath", "J", nil,
-		"Specify an additional library search dir",
-	)
-	renderCommand.PersistentFlags().StringVarP(
-		&renderCommandRenderOptions.MultiDir, "multi", "m", ".",
-		"Write multiple files to the directory, list files on stdout",
-	)
-	renderCommand.PersistentFlags().StringVarP(
-		&renderCommandRenderOptions.Header, "header", "H", "",
-		"Write header to each file",
-	)
-	renderCommand.PersistentFlags().StringVarP(
-		&renderCommandRenderOptions.FilenamePrefix, "prefix", "p", "",
-		"Prefix to append to every emitted file",
-	)
+type renderCommand struct {
+	vmOptions     jsonnetvm.Options
+	renderOptions render.Options
+	outputFlags
+	entrypointEvaluator
+	watchFlags
 }
 
-func handleYAMLFileType(k string, data interface{}) error {
+func (c *renderCommand) handleYAMLFileType(k string, data interface{}) (*render.File, error) {
+	var file *render.File
+
 	var err error
+
 	switch v := data.(type) {
 	case string:
-		err = render.YAMLStringData(k, v, renderCommandRenderOptions)
+		file, err = render.YAMLStringData(k, v, c.renderOptions)
 	case map[string]interface{}:
-		err = render.YAMLMapData(k, v, renderCommandRenderOptions)
+		file, err = render.YAMLMapData(k, v, c.renderOptions)
+	case []interface{}:
+		file, err = render.YAMLStreamData(k, v, c.renderOptions)
 	default:
 		err = fmt.Errorf("unexpected type in map for key `%v`: %T: %w", k, v, errCommandFailed)
 	}
 
 	if err != nil {
-		return fmt.Errorf("unable to render YAML: %w: %w", err, errCommandFailed)
+		return nil, fmt.Errorf("unable to render YAML: %w: %w", err, errCommandFailed)
 	}
 
-	return nil
+	return file, nil
 }
 
-func handleRenderFile(k string, data interface{}) error {
+func (c *renderCommand) handleRenderFile(k string, data interface{}) (*render.File, error) {
+	var file *render.File
+
 	var err error
 
 	switch path.Ext(k) {
 	case ".yml":
-		err = handleYAMLFileType(k, data)
+		file, err = c.handleYAMLFileType(k, data)
 	case ".yaml":
-		err = handleYAMLFileType(k, data)
+		file, err = c.handleYAMLFileType(k, data)
+	case ".toml":
+		file, err = render.TOMLData(k, data, c.renderOptions)
 	default:
-		err = render.JSONData(k, data, renderCommandRenderOptions)
+		file, err = render.JSONData(k, data, c.renderOptions)
 	}
 
 	if err != nil {
-		return fmt.Errorf("write failed: %w: %w", err, errCommandFailed)
+		return nil, fmt.Errorf("write failed: %w: %w", err, errCommandFailed)
 	}
 
-	return nil
+	return file, nil
 }
 
-var renderCommand = &cobra.Command{
-	Use:   "render",
-	Short: "Render files from Jsonnet using sensible defaults",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		vm := jsonnet.MakeVM()
-		natives.Register(vm)
+func (c *renderCommand) evaluate(vm *jsonnet.VM, entrypoint string) ([]*render.File, error) {
+	jsonData, err := vm.EvaluateFile(entrypoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate jsonnet: %w: %w", err, errCommandFailed)
+	}
 
-		vm.ErrorFormatter.SetColorFormatter(color.New(color.FgRed).Fprintf)
+	m := make(map[string]interface{})
+	err = render.DecodeJSON([]byte(jsonData), &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal json data: %w: %w", err, errCommandFailed)
+	}
 
-		vm.Importer(&jsonnet.FileImporter{
-			JPaths: renderCommandJPaths,
-		})
+	files := make([]*render.File, 0, len(m))
 
-		jsonData, err := vm.EvaluateFile(args[0])
+	for k, data := range m {
+		file, err := c.handleRenderFile(k, data)
 		if err != nil {
-			return fmt.Errorf("failed to evaluate jsonnet: %w: %w", err, errCommandFailed)
+			return nil, fmt.Errorf("failed to render file: %w: %w", err, errCommandFailed)
 		}
 
-		m := make(map[string]interface{})
-		err = json.Unmarshal([]byte(jsonData), &m)
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal json data: %w: %w", err, errCommandFailed)
-		}
+		file.Source = entrypoint
+		files = append(files, file)
+	}
 
-		for k, data := range m {
-			err = handleRenderFile(k, data)
-			if err != nil {
-				return fmt.Errorf("failed to render file: %w: %w", err, errCommandFailed)
-			}
+	return files, nil
+}
 
-		}
+func (c *renderCommand) RunE(cmd *cobra.Command, args []string) error {
+	err := c.outputFlags.validate(c.renderOptions)
+	if err != nil {
+		return err
+	}
+
+	err = validateJSONFlags(c.renderOptions)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	},
+	cmd.SilenceUsage = true
+
+	c.renderOptions.Version = toolVersion()
+
+	if c.watch {
+		return c.watchEntrypoints(cmd, args)
+	}
+
+	vmBuilder, err := c.vmOptions.NewBuilder(args, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	files, err := c.evaluateEntrypoints(args, vmBuilder.MakeVM, c.evaluate)
+	if err != nil {
+		return err
+	}
+
+	return c.emitFiles(cmd, files, c.renderOptions, vmBuilder.MakeVM)
+}
+
+func (c *renderCommand) watchEntrypoints(cmd *cobra.Command, args []string) error {
+	if c.check || c.dryRun {
+		return fmt.Errorf("--watch cannot be used with --check or --dry-run: %w", errCommandFailed)
+	}
+
+	w := &entrypointWatcher{
+		cmd:      cmd,
+		debounce: c.debounce,
+		options:  c.renderOptions,
+		depfile:  c.depfile,
+		newBuilder: func() (*jsonnetvm.Builder, error) {
+			return c.vmOptions.NewBuilder(args, cmd.ErrOrStderr())
+		},
+		render: func(builder *jsonnetvm.Builder, entrypoints []string) ([]*render.File, error) {
+			return c.evaluateEntrypoints(entrypoints, builder.MakeVM, c.evaluate)
+		},
+		validate: func(files []*render.File) error {
+			return c.validateSchemas(cmd, files, c.renderOptions)
+		},
+		write: func(files []*render.File) (bool, error) {
+			return c.writeFiles(cmd, files, c.renderOptions)
+		},
+	}
+
+	return w.run(args)
+}
+
+func NewRenderCommand() *cobra.Command {
+	c := &renderCommand{}
+
+	command := &cobra.Command{
+		Use:   "render [flags] <file>...",
+		Short: "Render files from Jsonnet using sensible defaults",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.MultiDir, "multi", "m", ".",
+		"Write multiple files to the directory, list files on stdout",
+	)
+	_ = command.MarkPersistentFlagDirname("multi")
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.Header, "header", "H", "",
+		"Write header to each file, as a comment. Supports the {{.Source}}, {{.Path}}, {{.Version}} and {{.Hash}} placeholders",
+	)
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.HeaderPolicy, "header-policy", "", render.HeaderPolicyWarn,
+		fmt.Sprintf("Header handling for files which cannot carry comments, such as JSON, one of: %s", strings.Join(render.HeaderPolicies, ", ")),
+	)
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.FilenamePrefix, "prefix", "p", "",
+		"Prefix to append to every emitted file",
+	)
+	addYAMLFlags(command.PersistentFlags(), &c.renderOptions)
+	addJSONFlags(command.PersistentFlags(), &c.renderOptions)
+	c.outputFlags.addFlags(command.PersistentFlags())
+	c.entrypointEvaluator.addFlags(command.PersistentFlags())
+	c.watchFlags.addFlags(command.PersistentFlags(), "Keep running, rendering entrypoints again whenever a file they import changes")
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewRenderCommand())
 }
@@ -0,0 +1,547 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+)
+
+var renderCheckFixtures = []struct {
+	name       string
+	command    func() *cobra.Command
+	args       []string
+	modify     func(t *testing.T, dir string)
+	exitCode   int
+	wantOutput string
+}{
+	{
+		name:    "render_up_to_date",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+	},
+	{
+		name:    "render_changed",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "moo2.yaml"), []byte("moo2: false\n"), 0644))
+		},
+		exitCode:   4,
+		wantOutput: "changed",
+	},
+	{
+		name:    "render_missing",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.Remove(filepath.Join(dir, "file.json")))
+		},
+		exitCode:   4,
+		wantOutput: "missing",
+	},
+	{
+		name:    "render_unowned_without_manifest",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.Remove(filepath.Join(dir, ".jsonnet-tool-manifest.json")))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "manual.json"), []byte("{}"), 0644))
+		},
+	},
+	{
+		name:    "render_changed_without_manifest",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.Remove(filepath.Join(dir, ".jsonnet-tool-manifest.json")))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "moo2.yaml"), []byte("moo2: false\n"), 0644))
+		},
+		exitCode:   4,
+		wantOutput: "changed",
+	},
+	{
+		name:    "render_extra_with_manifest",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+
+			manifestPath := filepath.Join(dir, ".jsonnet-tool-manifest.json")
+			manifest, err := os.ReadFile(manifestPath)
+			require.NoError(t, err)
+
+			manifest = bytes.Replace(manifest, []byte(`"file.ini",`), []byte(`"file.ini", "stale.json",`), 1)
+			require.NoError(t, os.WriteFile(manifestPath, manifest, 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "stale.json"), []byte("{}"), 0644))
+		},
+		exitCode:   4,
+		wantOutput: "extra",
+	},
+	{
+		name:    "render_unowned_with_manifest",
+		command: NewRenderCommand,
+		args:    []string{"-J", "../examples/test_lib", "../examples/render.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "manual.json"), []byte("{}"), 0644))
+		},
+	},
+	{
+		name:    "yaml_up_to_date",
+		command: NewYAMLCommand,
+		args:    []string{"-J", "../examples/test_lib", "-P", "there", "../examples/yaml.jsonnet"},
+	},
+	{
+		name:    "yaml_changed",
+		command: NewYAMLCommand,
+		args:    []string{"-J", "../examples/test_lib", "-P", "there", "../examples/yaml.jsonnet"},
+		modify: func(t *testing.T, dir string) {
+			t.Helper()
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "moo.yaml"), []byte("hello: true\n"), 0644))
+		},
+		exitCode:   4,
+		wantOutput: "changed",
+	},
+}
+
+// TestRenderCheck renders files, optionally modifies the output, and then verifies the result using --check.
+func TestRenderCheck(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range renderCheckFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			args := append([]string{"--multi", dir}, tt.args...)
+
+			_, err := executeCommand(tt.command(), args)
+			require.NoError(t, err)
+
+			if tt.modify != nil {
+				tt.modify(t, dir)
+			}
+
+			output, err := executeCommand(tt.command(), append([]string{"--check"}, args...))
+
+			if tt.exitCode == 0 {
+				require.NoError(t, err)
+			} else {
+				var errWithExitCode *exitcode.Error
+				if errors.As(err, &errWithExitCode) {
+					assert.EqualValues(t, tt.exitCode, errWithExitCode.ExitCode)
+				} else {
+					assert.NoError(t, err, "unexpected error response did not include exit code")
+				}
+			}
+
+			assert.Contains(t, output, tt.wantOutput)
+		})
+	}
+}
+
+// TestRenderPrune verifies that only files previously generated by an entrypoint are pruned.
+func TestRenderPrune(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	entrypoint := filepath.Join(dir, "entrypoint.jsonnet")
+	outputDir := filepath.Join(dir, "output")
+
+	writeEntrypoint := func(source string) {
+		require.NoError(t, os.WriteFile(entrypoint, []byte(source), 0644))
+	}
+
+	writeEntrypoint(`{ 'a.json': {}, 'sub/b.json': {} }`)
+	_, err := executeCommand(NewRenderCommand(), []string{"--multi", outputDir, entrypoint})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "manual.json"), []byte("{}"), 0644))
+	writeEntrypoint(`{ 'a.json': {} }`)
+
+	output, err := executeCommand(NewRenderCommand(), []string{"--multi", outputDir, "--prune", "--dry-run", entrypoint})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(outputDir, "sub", "b.json")+"\n", output)
+	assert.FileExists(t, filepath.Join(outputDir, "sub", "b.json"))
+
+	_, err = executeCommand(NewRenderCommand(), []string{"--multi", outputDir, "--prune", entrypoint})
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(outputDir, "sub", "b.json"))
+	assert.NoDirExists(t, filepath.Join(outputDir, "sub"))
+	assert.FileExists(t, filepath.Join(outputDir, "a.json"))
+	assert.FileExists(t, filepath.Join(outputDir, "manual.json"))
+}
+
+// TestRenderMultipleEntrypoints verifies that several entrypoints can be rendered in a single invocation.
+func TestRenderMultipleEntrypoints(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "output")
+
+	entrypoints := map[string]string{
+		"a.jsonnet":         `{ 'a.json': import 'lib.libsonnet' }`,
+		"b.jsonnet":         `{ 'b.yaml': import 'lib.libsonnet' }`,
+		"conflict.jsonnet":  `{ 'a.json': {} }`,
+		"lib/lib.libsonnet": `{ shared: true }`,
+	}
+
+	for name, source := range entrypoints {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(source), 0644))
+	}
+
+	_, err := executeCommand(NewRenderCommand(), []string{
+		"--multi", outputDir, "--jobs", "2", "-J", filepath.Join(dir, "lib"),
+		filepath.Join(dir, "a.jsonnet"), filepath.Join(dir, "b.jsonnet"),
+	})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(outputDir, "a.json"))
+	assert.FileExists(t, filepath.Join(outputDir, "b.yaml"))
+
+	_, err = executeCommand(NewRenderCommand(), []string{
+		"--multi", outputDir, "-J", filepath.Join(dir, "lib"),
+		filepath.Join(dir, "a.jsonnet"), filepath.Join(dir, "conflict.jsonnet"),
+	})
+	require.ErrorContains(t, err, "is generated by both")
+}
+
+// TestRenderOutputFormat verifies the machine-readable listing of generated files.
+func TestRenderOutputFormat(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	output, err := executeCommand(NewRenderCommand(), []string{
+		"--multi", dir, "--output-format", "json", "-J", "../examples/test_lib", "../examples/render.jsonnet",
+	})
+	require.NoError(t, err)
+
+	var report struct {
+		Files []struct {
+			Path   string `json:"path"`
+			Source string `json:"source"`
+			Size   int    `json:"size"`
+			SHA256 string `json:"sha256"`
+			Format string `json:"format"`
+		} `json:"files"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	require.Len(t, report.Files, 5)
+
+	formats := map[string]string{}
+
+	for _, f := range report.Files {
+		assert.Equal(t, "../examples/render.jsonnet", f.Source)
+		assert.Len(t, f.SHA256, 64)
+
+		b, err := os.ReadFile(f.Path)
+		require.NoError(t, err)
+		assert.Len(t, b, f.Size)
+
+		formats[filepath.Base(f.Path)] = f.Format
+	}
+
+	assert.Equal(t, map[string]string{
+		"file.ini":  "plain",
+		"file.json": "json",
+		"file.toml": "toml",
+		"moo.yaml":  "yaml",
+		"moo2.yaml": "yaml",
+	}, formats)
+
+	output, err = executeCommand(NewRenderCommand(), []string{
+		"--multi", dir, "-J", "../examples/test_lib", "../examples/render.jsonnet",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		filepath.Join(dir, "file.ini"),
+		filepath.Join(dir, "file.json"),
+		filepath.Join(dir, "file.toml"),
+		filepath.Join(dir, "moo.yaml"),
+		filepath.Join(dir, "moo2.yaml"),
+	}, "\n")+"\n", output)
+}
+
+// executeCommand executes a command with given arguments and flags, and returns the output.
+func executeCommand(command *cobra.Command, args []string) (string, error) {
+	buf := new(bytes.Buffer)
+	command.SetOut(buf)
+	command.SetErr(buf)
+	command.SetArgs(args)
+
+	err := command.Execute()
+
+	return buf.String(), err
+}
+
+// TestRenderDepfile verifies that the depfile lists the outputs and transitive imports of each entrypoint.
+func TestRenderDepfile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "output")
+	depfile := filepath.Join(dir, "render.d")
+
+	sources := map[string]string{
+		"a.jsonnet":            `{ 'a.json': import 'lib.libsonnet', 'a b.yaml': { text: importstr 'data.txt' } }`,
+		"b.jsonnet":            `{}`,
+		"lib/lib.libsonnet":    `import 'nested.libsonnet'`,
+		"lib/nested.libsonnet": `{ nested: true }`,
+		"data.txt":             "text",
+	}
+
+	for name, source := range sources {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(source), 0644))
+	}
+
+	_, err := executeCommand(NewRenderCommand(), []string{
+		"--multi", outputDir, "--depfile", depfile, "-J", filepath.Join(dir, "lib"),
+		filepath.Join(dir, "a.jsonnet"), filepath.Join(dir, "b.jsonnet"),
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(depfile)
+	require.NoError(t, err)
+
+	want := strings.Join([]string{
+		filepath.Join(outputDir, `a\ b.yaml`) + " " + filepath.Join(outputDir, "a.json") + `: \`,
+		"  " + filepath.Join(dir, "a.jsonnet") + ` \`,
+		"  " + filepath.Join(dir, "data.txt") + ` \`,
+		"  " + filepath.Join(dir, "lib", "lib.libsonnet") + ` \`,
+		"  " + filepath.Join(dir, "lib", "nested.libsonnet"),
+		"",
+	}, "\n")
+	assert.Equal(t, want, string(content))
+}
+
+func TestRenderJSONStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "default",
+			want: "{\n  \"f\": 0.1,\n  \"html\": \"\\u003ca\\u003e\",\n  \"n\": 1000000000000000000000\n}",
+		},
+		{
+			name: "indent_and_trailing_newline",
+			args: []string{"--json-indent", "4", "--json-trailing-newline"},
+			want: "{\n    \"f\": 0.1,\n    \"html\": \"\\u003ca\\u003e\",\n    \"n\": 1000000000000000000000\n}\n",
+		},
+		{
+			name: "compact_without_html_escaping",
+			args: []string{"--json-compact", "--json-no-html-escape"},
+			want: `{"f":0.1,"html":"<a>","n":1000000000000000000000}`,
+		},
+		{
+			name: "preserve_numbers",
+			args: []string{"--json-compact", "--json-preserve-numbers"},
+			want: `{"f":0.10000000000000001,"html":"\u003ca\u003e","n":1000000000000000000000}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			entrypoint := filepath.Join(dir, "render.jsonnet")
+			require.NoError(t, os.WriteFile(entrypoint, []byte(`{ 'a.json': { f: 0.1, html: '<a>', n: 1e21 } }`), 0644))
+
+			_, err := executeCommand(NewRenderCommand(), append([]string{"--multi", dir, entrypoint}, tt.args...))
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(filepath.Join(dir, "a.json"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(content))
+		})
+	}
+}
+
+// TestRenderNumbers verifies that numbers are written exactly as Jsonnet formats them, rather than
+// being rounded to the precision of a float64 or written in exponent form.
+func TestRenderNumbers(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	entrypoint := filepath.Join(dir, "render.jsonnet")
+	source := `
+		local numbers = {
+			maxSafe: 9007199254740992,
+			minInt64: -9223372036854775808,
+			beyondInt64: 9223372036854775808,
+			large: 12345678901234567168,
+			million: 1e6,
+		};
+		{ 'numbers.json': numbers, 'numbers.yaml': numbers }
+	`
+	require.NoError(t, os.WriteFile(entrypoint, []byte(source), 0644))
+
+	_, err := executeCommand(NewRenderCommand(), []string{"--multi", dir, "--json-compact", entrypoint})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "numbers.json"))
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"beyondInt64":9223372036854775808,"large":12345678901234567168,"maxSafe":9007199254740992,"million":1000000,"minInt64":-9223372036854775808}`,
+		string(content))
+
+	content, err = os.ReadFile(filepath.Join(dir, "numbers.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t,
+		"beyondInt64: 9223372036854775808\nlarge: 12345678901234567168\nmaxSafe: 9007199254740992\nmillion: 1000000\nminInt64: -9223372036854775808\n",
+		string(content))
+}
+
+// TestRenderSchema verifies that files are validated against the schemas mapped to their paths
+// before they are written, and that violations fail the run with a dedicated exit code.
+func TestRenderSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		command    func() *cobra.Command
+		source     string
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name:    "render_valid",
+			command: NewRenderCommand,
+			source:  `{ 'services/a.yaml': { name: 'a' }, 'other.json': { name: 1 } }`,
+		},
+		{
+			name:       "render_invalid",
+			command:    NewRenderCommand,
+			source:     `{ 'services/a.yaml': { name: 1 } }`,
+			wantOutput: filepath.Join("services", "a.yaml") + ": #/name: expected string, but got number",
+			wantErr:    true,
+		},
+		{
+			name:       "yaml_invalid",
+			command:    NewYAMLCommand,
+			source:     `{ 'services/a.yaml': std.manifestYamlDoc({ port: 1 }) }`,
+			wantOutput: filepath.Join("services", "a.yaml") + ": #: missing properties: 'name'",
+			wantErr:    true,
+		},
+		{
+			name:       "yaml_invalid_without_yaml_extension",
+			command:    NewYAMLCommand,
+			source:     `{ 'services/a.yaml': 'name: a', 'services/b.rules': std.manifestYamlDoc({ port: 1 }) }`,
+			wantOutput: filepath.Join("services", "b.rules") + ": #: missing properties: 'name'",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			outputDir := filepath.Join(dir, "output")
+			entrypoint := filepath.Join(dir, "render.jsonnet")
+			schemaPath := filepath.Join(dir, "schema.json")
+
+			require.NoError(t, os.WriteFile(entrypoint, []byte(tt.source), 0644))
+			require.NoError(t, os.WriteFile(schemaPath, []byte(`{"required": ["name"], "properties": {"name": {"type": "string"}}}`), 0644))
+
+			output, err := executeCommand(tt.command(), []string{"--multi", outputDir, "--schema", "services/*=" + schemaPath, entrypoint})
+			assert.Contains(t, output, tt.wantOutput)
+
+			if !tt.wantErr {
+				require.NoError(t, err)
+				assert.FileExists(t, filepath.Join(outputDir, "services", "a.yaml"))
+
+				return
+			}
+
+			var errWithExitCode *exitcode.Error
+			require.ErrorAs(t, err, &errWithExitCode)
+			assert.Equal(t, 5, errWithExitCode.ExitCode)
+			assert.NoDirExists(t, outputDir)
+		})
+	}
+}
+
+// TestRenderInvalidFlags verifies that invalid flags are rejected before any entrypoint is evaluated.
+func TestRenderInvalidFlags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		command func() *cobra.Command
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "render_dry_run_without_prune",
+			command: NewRenderCommand,
+			args:    []string{"--dry-run"},
+			wantErr: "--dry-run can only be used with --prune",
+		},
+		{
+			name:    "yaml_check_with_prune",
+			command: NewYAMLCommand,
+			args:    []string{"--check", "--prune"},
+			wantErr: "--check cannot be used with --prune",
+		},
+		{
+			name:    "render_negative_yaml_indent",
+			command: NewRenderCommand,
+			args:    []string{"--yaml-indent", "-1"},
+			wantErr: "invalid --yaml-indent -1",
+		},
+		{
+			name:    "render_negative_json_indent",
+			command: NewRenderCommand,
+			args:    []string{"--json-indent", "-1"},
+			wantErr: "invalid --json-indent -1",
+		},
+		{
+			name:    "render_zero_json_indent",
+			command: NewRenderCommand,
+			args:    []string{"--json-indent", "0"},
+			wantErr: "invalid --json-indent 0",
+		},
+		{
+			name:    "yaml_unknown_quote_style",
+			command: NewYAMLCommand,
+			args:    []string{"--yaml-quote-style", "bogus"},
+			wantErr: `unknown YAML quote style "bogus"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			entrypoint := filepath.Join(dir, "render.jsonnet")
+			require.NoError(t, os.WriteFile(entrypoint, []byte(`error 'evaluated'`), 0644))
+
+			_, err := executeCommand(tt.command(), append([]string{"--multi", dir, entrypoint}, tt.args...))
+			require.ErrorContains(t, err, tt.wantErr)
+			require.ErrorIs(t, err, errCommandFailed)
+		})
+	}
+}
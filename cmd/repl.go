@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/repl"
+)
+
+const (
+	replPrompt             = "jsonnet> "
+	replContinuationPrompt = "...      "
+
+	// replHistoryFileName is the name of the history file, within the home directory by default.
+	replHistoryFileName = ".jsonnet-tool-repl-history"
+)
+
+// replPrompter reads lines of input, recording them in the history.
+type replPrompter interface {
+	Prompt(prompt string) (string, error)
+	AppendHistory(item string)
+}
+
+type replCommand struct {
+	vmOptions     jsonnetvm.Options
+	renderOptions render.Options
+	outputFormat  string
+	historyFile   string
+}
+
+func (c *replCommand) RunE(cmd *cobra.Command, args []string) error {
+	err := validateYAMLFlags(c.renderOptions)
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+
+	makeVM := func() (*jsonnet.VM, error) {
+		// Any jsonnet-bundler project containing the working directory is detected
+		vmBuilder, err := c.vmOptions.NewBuilder([]string{repl.SnippetName}, cmd.ErrOrStderr())
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure jsonnet: %w", err)
+		}
+
+		return vmBuilder.MakeVM(), nil
+	}
+
+	session, err := repl.New(makeVM, repl.Options{OutputFormat: c.outputFormat, Render: c.renderOptions})
+	if err != nil {
+		return fmt.Errorf("failed to start session: %w: %w", err, errCommandFailed)
+	}
+
+	line := liner.NewLiner()
+	defer line.Close()
+
+	line.SetCtrlCAborts(true)
+
+	c.readHistory(cmd, line)
+	defer c.writeHistory(cmd, line)
+
+	_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Use :help to list commands, :quit or Ctrl-D to leave")
+
+	return runREPL(session, line, cmd.OutOrStdout(), cmd.ErrOrStderr())
+}
+
+// runREPL executes inputs read by prompter until the input ends or the user quits. Inputs
+// which are incomplete are continued on the next line, and errors are reported to errOut.
+func runREPL(session *repl.Session, prompter replPrompter, out io.Writer, errOut io.Writer) error {
+	var lines []string
+
+	for {
+		prompt := replPrompt
+		if len(lines) > 0 {
+			prompt = replContinuationPrompt
+		}
+
+		line, err := prompter.Prompt(prompt)
+
+		switch {
+		case errors.Is(err, liner.ErrPromptAborted):
+			// Ctrl-C discards the current input, rather than leaving the session
+			lines = nil
+			continue
+		case errors.Is(err, io.EOF):
+			_, _ = fmt.Fprintln(out)
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to read input: %w: %w", err, errCommandFailed)
+		}
+
+		if strings.TrimSpace(line) != "" {
+			prompter.AppendHistory(line)
+		}
+
+		lines = append(lines, line)
+
+		err = session.Execute(strings.Join(lines, "\n"), out)
+
+		switch {
+		case errors.Is(err, repl.ErrIncomplete):
+			continue
+		case errors.Is(err, repl.ErrQuit):
+			return nil
+		case err != nil:
+			_, _ = fmt.Fprintf(errOut, "error: %v\n", strings.TrimSpace(err.Error()))
+		}
+
+		lines = nil
+	}
+}
+
+// readHistory loads the history file, if there is one. Failures are reported, rather than
+// returned, as the session is still usable without history.
+func (c *replCommand) readHistory(cmd *cobra.Command, line *liner.State) {
+	if c.historyFile == "" {
+		return
+	}
+
+	f, err := os.Open(c.historyFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "failed to read history: %v\n", err)
+		}
+
+		return
+	}
+	defer f.Close()
+
+	_, err = line.ReadHistory(f)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "failed to read history: %v\n", err)
+	}
+}
+
+// writeHistory saves the history to the history file.
+func (c *replCommand) writeHistory(cmd *cobra.Command, line *liner.State) {
+	if c.historyFile == "" {
+		return
+	}
+
+	f, err := os.Create(c.historyFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "failed to write history: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	_, err = line.WriteHistory(f)
+	if err != nil {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "failed to write history: %v\n", err)
+	}
+}
+
+// defaultREPLHistoryFile returns the path of the history file in the home directory,
+// or an empty string, disabling history, if there is no home directory.
+func defaultREPLHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, replHistoryFileName)
+}
+
+func NewREPLCommand() *cobra.Command {
+	c := &replCommand{}
+
+	command := &cobra.Command{
+		Use:   "repl",
+		Short: "Evaluate Jsonnet interactively",
+		Long: "Evaluate Jsonnet expressions interactively, using the same natives, library search path and " +
+			"external variables as the other commands. Local bindings, such as `local lib = import 'lib.libsonnet';`, " +
+			"are available to every later input. Use :help within the session to list commands.",
+		Args: cobra.NoArgs,
+		RunE: c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().StringVarP(
+		&c.outputFormat, "output-format", "o", repl.OutputFormatJSON,
+		fmt.Sprintf("Format of results, one of: %s. Can be changed within the session using :format", strings.Join(repl.OutputFormats, ", ")),
+	)
+	command.PersistentFlags().StringVarP(
+		&c.historyFile, "history-file", "", defaultREPLHistoryFile(),
+		"File in which input history is kept between sessions, or empty to disable history",
+	)
+	_ = cobra.MarkFlagFilename(command.PersistentFlags(), "history-file")
+	addYAMLFlags(command.PersistentFlags(), &c.renderOptions)
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewREPLCommand())
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/peterh/liner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/repl"
+)
+
+// testPrompter returns each input in turn, as though typed by the user, followed by io.EOF.
+type testPrompter struct {
+	inputs  []interface{}
+	prompts []string
+	history []string
+}
+
+func (p *testPrompter) Prompt(prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+
+	if len(p.inputs) == 0 {
+		return "", io.EOF
+	}
+
+	input := p.inputs[0]
+	p.inputs = p.inputs[1:]
+
+	if err, ok := input.(error); ok {
+		return "", err
+	}
+
+	return input.(string), nil
+}
+
+func (p *testPrompter) AppendHistory(item string) {
+	p.history = append(p.history, item)
+}
+
+func TestRunREPL(t *testing.T) {
+	t.Parallel()
+
+	session, err := repl.New(func() (*jsonnet.VM, error) { return jsonnet.MakeVM(), nil }, repl.Options{OutputFormat: repl.OutputFormatJSON})
+	require.NoError(t, err)
+
+	prompter := &testPrompter{
+		inputs: []interface{}{
+			"local a = {",
+			"  b: 1,",
+			"};",
+			"[",
+			liner.ErrPromptAborted,
+			"a.b",
+			"",
+			"nope",
+		},
+	}
+
+	var out, errOut bytes.Buffer
+
+	err = runREPL(session, prompter, &out, &errOut)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1\n\n", out.String())
+	assert.Contains(t, errOut.String(), "error: <repl>:4:1-5 Unknown variable: nope")
+	assert.Equal(t, []string{
+		replPrompt, replContinuationPrompt, replContinuationPrompt,
+		replPrompt, replContinuationPrompt,
+		replPrompt, replPrompt, replPrompt, replPrompt,
+	}, prompter.prompts)
+	assert.Equal(t, []string{"local a = {", "  b: 1,", "};", "[", "a.b", "nope"}, prompter.history)
+}
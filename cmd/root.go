@@ -4,8 +4,13 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/config"
 )
 
+var configPath string
+
 var rootCmd = &cobra.Command{
 	Use:   "jsonnet-tool",
 	Short: "A tool for rendering jsonnet",
@@ -20,3 +25,95 @@ func Execute() error {
 
 	return nil
 }
+
+// loadProjectConfig loads the configuration file given by --config, or otherwise the
+// configuration file found in the working directory or its parents. Returns nil when
+// there is no configuration file.
+func loadProjectConfig() (*config.Config, error) {
+	path := configPath
+	if path == "" {
+		var err error
+
+		path, err = config.Find(".")
+		if err != nil {
+			return nil, fmt.Errorf("failed to find configuration: %w: %w", err, errCommandFailed)
+		}
+
+		if path == "" {
+			return nil, nil
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w: %w", err, errCommandFailed)
+	}
+
+	err = cfg.Validate(commandFlags())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w: %w", err, errCommandFailed)
+	}
+
+	return cfg, nil
+}
+
+// applyProjectConfig sets any flags of cmd which were not given on the command line from the project configuration.
+// The configuration is only loaded for commands it can configure, so that others, such as version, still work when
+// it is invalid.
+func applyProjectConfig(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	if !configurable(cmd) {
+		return nil
+	}
+
+	cfg, err := loadProjectConfig()
+	if err != nil || cfg == nil {
+		return err
+	}
+
+	err = cfg.Apply(cmd.Name(), cmd.Flags())
+	if err != nil {
+		return fmt.Errorf("failed to apply configuration: %w: %w", err, errCommandFailed)
+	}
+
+	return nil
+}
+
+// configurable returns true for commands with flags which the project configuration can set: the subcommands
+// of the root command with any flags other than --help.
+func configurable(cmd *cobra.Command) bool {
+	if cmd.Parent() != rootCmd {
+		return false
+	}
+
+	hasFlags := false
+
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		hasFlags = hasFlags || flag.Name != "help"
+	})
+
+	return hasFlags
+}
+
+// commandFlags returns the flags of every subcommand, keyed on the command name.
+func commandFlags() map[string]*pflag.FlagSet {
+	commands := map[string]*pflag.FlagSet{}
+	for _, c := range rootCmd.Commands() {
+		commands[c.Name()] = c.LocalFlags()
+	}
+
+	return commands
+}
+
+func init() {
+	// Run the root PersistentPreRunE, which applies any project configuration, as well as those of subcommands
+	cobra.EnableTraverseRunHooks = true
+	rootCmd.PersistentPreRunE = applyProjectConfig
+
+	rootCmd.PersistentFlags().StringVar(
+		&configPath, "config", "",
+		fmt.Sprintf("Project configuration file. By default, %s is found in the working directory or its parents", config.FileName),
+	)
+	_ = rootCmd.MarkPersistentFlagFilename("config", "yaml")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigurable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "render", args: []string{"render"}, want: true},
+		{name: "test", args: []string{"test"}, want: true},
+		{name: "version", args: []string{"version"}, want: false},
+		{name: "config", args: []string{"config"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd, _, err := rootCmd.Find(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, configurable(cmd))
+		})
+	}
+}
@@ -2,25 +2,39 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
 
-	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/pkg/natives"
-
-	"github.com/fatih/color"
-	jsonnet "github.com/google/go-jsonnet"
 	"github.com/spf13/cobra"
 
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
 	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/manitest"
 )
 
 type testCommand struct {
-	testCommandJPaths []string
-	writeFixtures     bool
-	cacheResults      bool
-	jsonnetExtVars    map[string]string
-	emitAllTraces     bool
+	vmOptions     jsonnetvm.Options
+	writeFixtures bool
+	cacheResults  bool
+	emitAllTraces bool
+	watchFlags
 }
 
 func (c *testCommand) RunE(cmd *cobra.Command, args []string) error {
+	if c.watch {
+		return c.watchTests(cmd, args)
+	}
+
+	vmBuilder, err := c.vmOptions.NewBuilder(args, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	return c.runTestFiles(cmd, args, vmBuilder)
+}
+
+// runTestFiles runs the test files, returning an error carrying the exit code if any tests
+// failed or were invalid. Any additional visitors are notified of every test event.
+func (c *testCommand) runTestFiles(cmd *cobra.Command, args []string, vmBuilder *jsonnetvm.Builder, additionalVisitors ...manitest.TestVisitor) error {
 	traceVisitor := manitest.NewTraceVisitor(cmd.OutOrStdout(), cmd.ErrOrStderr())
 	reporterVisitor := manitest.NewReporterVisitor(c.emitAllTraces, args, cmd.OutOrStdout(), cmd.ErrOrStderr())
 
@@ -33,22 +47,14 @@ func (c *testCommand) RunE(cmd *cobra.Command, args []string) error {
 		visitors = append(visitors, &manitest.WriterVisitor{})
 	}
 
-	vm := jsonnet.MakeVM()
-	natives.Register(vm)
-
-	for k, v := range c.jsonnetExtVars {
-		vm.ExtVar(k, v)
-	}
+	visitors = append(visitors, additionalVisitors...)
 
+	vm := vmBuilder.MakeVM()
 	vm.SetTraceOut(traceVisitor)
-	vm.ErrorFormatter.SetColorFormatter(color.New(color.FgRed).Fprintf)
-	vm.Importer(&jsonnet.FileImporter{
-		JPaths: c.testCommandJPaths,
-	})
 
 	var cacheManager *manitest.CacheManager
 	if c.cacheResults {
-		cacheManager = manitest.NewCacheManager(vm)
+		cacheManager = manitest.NewCacheManager(vm, vmBuilder.TopLevelCallArgs())
 
 		err := cacheManager.LoadCachedResults()
 		if err != nil {
@@ -66,7 +72,7 @@ func (c *testCommand) RunE(cmd *cobra.Command, args []string) error {
 	visitors = append(visitors, exitCodeVisitor)
 
 	visitor := &manitest.MultiVisitor{Visitors: visitors}
-	runner := manitest.NewTestRunner(vm, visitor)
+	runner := manitest.NewTestRunner(vm, visitor, vmBuilder.TopLevelCallArgs())
 
 	// Add required natives
 	runner.RegisterNatives()
@@ -91,6 +97,122 @@ func (c *testCommand) RunE(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// watchTests runs the test files, then runs them again whenever any of the files they depend upon,
+// including fixtures, change. Only the test files affected by a change are run again.
+func (c *testCommand) watchTests(cmd *cobra.Command, args []string) error {
+	w := &testWatcher{
+		command:      c,
+		cmd:          cmd,
+		testFiles:    args,
+		dependencies: dependencyGraph{},
+		statuses:     map[string]manitest.TestFileStatus{},
+	}
+
+	return watchEntrypoints(cmd, c.debounce, args, w.dependencies, w.update)
+}
+
+// testWatcher runs test files, then runs them again whenever a file they depend upon changes.
+type testWatcher struct {
+	command *testCommand
+	cmd     *cobra.Command
+	// testFiles are all of the watched test files, which configure jsonnet-bundler detection
+	testFiles []string
+
+	dependencies dependencyGraph
+	// statuses are the latest outcome of each test file, keyed on test file
+	statuses map[string]manitest.TestFileStatus
+}
+
+// update runs the test files and prints a summary of every test file. Failures are reported,
+// rather than returned, so that watching continues until the failure is fixed.
+func (w *testWatcher) update(files []string) {
+	// The test files and their project files are watched before the VM is built,
+	// so that watching continues if building it fails
+	seeds := map[string][]string{}
+
+	for _, f := range files {
+		deps, err := projectFiles(f)
+		if err != nil {
+			_, _ = fmt.Fprintf(w.cmd.ErrOrStderr(), "error: %v\n", err)
+			return
+		}
+
+		seeds[f] = deps
+		w.dependencies.add(f, deps...)
+	}
+
+	vmBuilder, err := w.command.vmOptions.NewBuilder(w.testFiles, w.cmd.ErrOrStderr())
+	if err != nil {
+		_, _ = fmt.Fprintf(w.cmd.ErrOrStderr(), "error: failed to configure jsonnet: %v\n", err)
+		return
+	}
+
+	cacheManager := manitest.NewCacheManager(vmBuilder.MakeVM(), vmBuilder.TopLevelCallArgs())
+
+	for _, f := range files {
+		deps, err := cacheManager.ListAllDependencies(f)
+		if err == nil {
+			deps, err = absPaths(deps)
+		}
+
+		err = w.dependencies.set(f, append(deps, seeds[f]...), err)
+		if err != nil {
+			_, _ = fmt.Fprintf(w.cmd.ErrOrStderr(), "error: %v\n", err)
+			return
+		}
+	}
+
+	statusVisitor := manitest.NewStatusVisitor()
+
+	// Failures are reported by the visitors, and watching continues until they are fixed
+	_ = w.command.runTestFiles(w.cmd, files, vmBuilder, statusVisitor)
+
+	for f, status := range statusVisitor.Statuses {
+		w.statuses[f] = status
+	}
+
+	_, _ = fmt.Fprintln(w.cmd.OutOrStdout(), watchSummary(w.statuses, len(w.dependencies.files())))
+}
+
+// watchSummary summarises the latest outcome of every test file.
+func watchSummary(statuses map[string]manitest.TestFileStatus, watched int) string {
+	counts := map[manitest.TestFileStatus]int{}
+	for _, status := range statuses {
+		counts[status]++
+	}
+
+	icon := "✅"
+	if counts[manitest.TestFileFailed] > 0 {
+		icon = "❌"
+	}
+
+	if counts[manitest.TestFileInvalid] > 0 {
+		icon = "💥"
+	}
+
+	return fmt.Sprintf("%s Watching %d files: %d test files, %d passed, %d failed, %d invalid. Waiting for changes...",
+		icon, watched, len(statuses),
+		counts[manitest.TestFilePassed], counts[manitest.TestFileFailed], counts[manitest.TestFileInvalid])
+}
+
+// absPaths returns the absolute form of each path.
+func absPaths(paths []string) ([]string, error) {
+	abs := make([]string, len(paths))
+
+	for i, p := range paths {
+		var err error
+
+		abs[i], err = filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", p, err)
+		}
+	}
+
+	slices.Sort(abs)
+
+	return abs, nil
+}
+
 func silenceErrorsUsage(cmd *cobra.Command, args []string) {
 	cmd.SilenceUsage = true
 	cmd.SilenceErrors = true
@@ -107,10 +229,7 @@ func NewTestCommand() *cobra.Command {
 		RunE:             t.RunE,
 	}
 
-	command.PersistentFlags().StringArrayVarP(
-		&t.testCommandJPaths, "jpath", "J", nil,
-		"Specify an additional library search dir",
-	)
+	t.vmOptions.AddFlags(command.PersistentFlags())
 
 	command.PersistentFlags().BoolVarP(
 		&t.writeFixtures, "write-fixtures", "w", false,
@@ -122,16 +241,13 @@ func NewTestCommand() *cobra.Command {
 		"Cache tests for unchanged files to improve test speed",
 	)
 
-	command.PersistentFlags().StringToStringVarP(
-		&t.jsonnetExtVars, "ext-str", "V", map[string]string{},
-		"Provide an external value as a string to jsonnet",
-	)
-
 	command.PersistentFlags().BoolVarP(
 		&t.emitAllTraces, "all-traces", "T", false,
 		"Emit all traces. By default, only traces for failed tests will be emitted",
 	)
 
+	t.watchFlags.addFlags(command.PersistentFlags(), "Keep running, running affected test files again whenever a file they depend upon changes")
+
 	return command
 }
 
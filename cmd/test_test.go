@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"errors"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 
 	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/manitest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -78,6 +82,24 @@ var testTestFixtures = []struct {
 		exitCode:   3,
 		wantOutput: "💥 Test suite completed: 3 files tested, 1 file passed, 1 file failed, 1 file invalid\n",
 	},
+	{
+		name:       "tla_defaults",
+		args:       []string{"../examples/tests/test6.tla.manitest.jsonnet"},
+		exitCode:   0,
+		wantOutput: "✅ Test suite completed: 1 file tested, 1 file passed\n",
+	},
+	{
+		name:       "tla_args",
+		args:       []string{"--tla-str", "environment=gstg", "--tla-code", "replicas=1", "../examples/tests/test6.tla.manitest.jsonnet"},
+		exitCode:   0,
+		wantOutput: "✅ Test suite completed: 1 file tested, 1 file passed\n",
+	},
+	{
+		name:       "tla_args_failure",
+		args:       []string{"--tla-str", "environment=gstg", "../examples/tests/test6.tla.manitest.jsonnet"},
+		exitCode:   1,
+		wantOutput: "❌ Test suite completed: 1 file tested, 0 files passed, 1 file failed\n",
+	},
 	{
 		name:        "cache_success",
 		deleteCache: true,
@@ -131,14 +153,88 @@ func TestTestCommand(t *testing.T) {
 
 // executeTestCommand executes the testCommand with given arguments and flags, and returns the output.
 func executeTestCommand(args []string) (string, error) {
-	testCommand := NewTestCommand()
+	return executeCommand(NewTestCommand(), args)
+}
+
+// newTestWatcher returns a testWatcher for the test files, writing output to stdout and stderr.
+func newTestWatcher(vmOptions jsonnetvm.Options, testFiles []string, stdout *bytes.Buffer, stderr *bytes.Buffer) *testWatcher {
+	cmd := &cobra.Command{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(stderr)
+
+	return &testWatcher{
+		command:      &testCommand{vmOptions: vmOptions},
+		cmd:          cmd,
+		testFiles:    testFiles,
+		dependencies: dependencyGraph{},
+		statuses:     map[string]manitest.TestFileStatus{},
+	}
+}
+
+// TestTestWatcherUpdate runs test files as watch mode would after each change, verifying that
+// only the test files which depend upon a changed library are run again.
+func TestTestWatcherUpdate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.libsonnet")
+	a := filepath.Join(dir, "a.manitest.jsonnet")
+	b := filepath.Join(dir, "b.manitest.jsonnet")
+	c := filepath.Join(dir, "c.manitest.jsonnet")
+
+	dependent := "local shared = import 'shared.libsonnet';\n{ testcase: function() { actual: shared.value, expect: 1 } }\n"
+
+	for name, content := range map[string]string{
+		shared: "{ value: 1 }\n",
+		a:      dependent,
+		b:      dependent,
+		c:      "{ testcase: function() { actual: 1, expect: 1 } }\n",
+	} {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	testFiles := []string{a, b, c}
+	w := newTestWatcher(jsonnetvm.Options{}, testFiles, &stdout, &stderr)
+
+	w.update(testFiles)
+	assert.Contains(t, stdout.String(), "3 files tested, 3 files passed")
+	assert.Contains(t, stdout.String(), "✅ Watching 4 files: 3 test files, 3 passed, 0 failed, 0 invalid.")
+
+	// Changing the shared library only runs the test files which import it
+	stdout.Reset()
+
+	require.NoError(t, os.WriteFile(shared, []byte("{ value: 2 }\n"), 0644))
+
+	affected := w.dependencies.affected(testFiles, []string{shared})
+	assert.Equal(t, []string{a, b}, affected)
+
+	w.update(affected)
+	assert.Contains(t, stdout.String(), "2 files tested, 0 files passed, 2 files failed")
+	assert.Contains(t, stdout.String(), "❌ Watching 4 files: 3 test files, 1 passed, 2 failed, 0 invalid.")
+	assert.Empty(t, stderr.String())
+}
+
+// TestTestWatcherInvalidProject verifies that when the VM cannot be built, because the jsonnetfile
+// is invalid, the error is reported and the test file and jsonnetfile are still watched.
+func TestTestWatcherInvalidProject(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "a.manitest.jsonnet")
+	jsonnetFile := filepath.Join(dir, "jsonnetfile.json")
+
+	require.NoError(t, os.WriteFile(testFile, []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(jsonnetFile, []byte("{"), 0644))
+
+	var stdout, stderr bytes.Buffer
 
-	buf := new(bytes.Buffer)
-	testCommand.SetOut(buf)
-	testCommand.SetErr(buf)
-	testCommand.SetArgs(args)
+	w := newTestWatcher(jsonnetvm.Options{JsonnetBundler: true}, []string{testFile}, &stdout, &stderr)
 
-	err := testCommand.Execute()
+	w.update([]string{testFile})
+	assert.Contains(t, stderr.String(), "error: failed to configure jsonnet: failed to load jsonnet-bundler project")
+	assert.Empty(t, stdout.String())
 
-	return buf.String(), err
+	assert.Equal(t, []string{testFile}, w.dependencies.affected([]string{testFile}, []string{jsonnetFile}))
 }
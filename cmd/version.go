@@ -10,6 +10,15 @@ var version = ""
 var commit = ""
 var date = ""
 
+// toolVersion returns the version of the binary, or "dev" for development builds.
+func toolVersion() string {
+	if version == "" {
+		return "dev"
+	}
+
+	return version
+}
+
 func init() {
 	rootCmd.AddCommand(versionCommand)
 }
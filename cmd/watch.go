@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/bundler"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/watch"
+)
+
+// watchFlags control watch mode, in which entrypoints are rendered again whenever
+// a file they depend upon changes.
+type watchFlags struct {
+	watch    bool
+	debounce time.Duration
+}
+
+func (w *watchFlags) addFlags(flags *pflag.FlagSet, usage string) {
+	flags.BoolVarP(
+		&w.watch, "watch", "", false,
+		usage,
+	)
+	flags.DurationVarP(
+		&w.debounce, "watch-debounce", "", 100*time.Millisecond,
+		"With --watch, how long to wait for further changes before running again",
+	)
+}
+
+// dependencyGraph holds the absolute paths of the files each entrypoint depends upon, keyed on entrypoint.
+type dependencyGraph map[string][]string
+
+// set records the dependencies of entrypoint. If the dependencies could not be determined, for example
+// due to a syntax error, the previous dependencies are retained, as they are likely to still apply, so
+// that the entrypoint is run again once the error has been fixed.
+func (d dependencyGraph) set(entrypoint string, deps []string, err error) error {
+	if err == nil {
+		deps = slices.Clone(deps)
+		slices.Sort(deps)
+		d[entrypoint] = slices.Compact(deps)
+
+		return nil
+	}
+
+	abs, err := filepath.Abs(entrypoint)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", entrypoint, err)
+	}
+
+	d.add(entrypoint, abs)
+
+	return nil
+}
+
+// add records further dependencies of entrypoint, alongside those already recorded.
+func (d dependencyGraph) add(entrypoint string, files ...string) {
+	deps := append(slices.Clone(d[entrypoint]), files...)
+	slices.Sort(deps)
+	d[entrypoint] = slices.Compact(deps)
+}
+
+// affected returns the entrypoints which depend upon any of the changed files.
+func (d dependencyGraph) affected(entrypoints []string, changed []string) []string {
+	var affected []string
+
+	for _, entrypoint := range entrypoints {
+		for _, f := range changed {
+			if _, found := slices.BinarySearch(d[entrypoint], f); found {
+				affected = append(affected, entrypoint)
+				break
+			}
+		}
+	}
+
+	return affected
+}
+
+// files returns every file that any entrypoint depends upon.
+func (d dependencyGraph) files() []string {
+	var files []string
+	for _, deps := range d {
+		files = append(files, deps...)
+	}
+
+	slices.Sort(files)
+
+	return slices.Compact(files)
+}
+
+// projectFiles returns the absolute paths of entrypoint and of the files of the jsonnet-bundler
+// project containing it, if any. These are known without evaluating the entrypoint, so they can
+// be watched even when a VM cannot be built, for example because the jsonnetfile is invalid.
+func projectFiles(entrypoint string) ([]string, error) {
+	abs, err := filepath.Abs(entrypoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", entrypoint, err)
+	}
+
+	dir, err := bundler.FindDir(filepath.Dir(abs))
+	if err != nil || dir == "" {
+		return []string{abs}, err
+	}
+
+	return []string{abs, filepath.Join(dir, bundler.JsonnetFile), filepath.Join(dir, bundler.LockFile)}, nil
+}
+
+// watchEntrypoints calls update with every entrypoint, then watches the files in dependencies,
+// calling update with the affected entrypoints whenever any of those files change, until interrupted.
+// update is expected to record the dependencies of the entrypoints it is called with.
+func watchEntrypoints(cmd *cobra.Command, debounce time.Duration, entrypoints []string, dependencies dependencyGraph, update func(entrypoints []string)) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher, err := watch.New(debounce)
+	if err != nil {
+		return fmt.Errorf("failed to watch: %w: %w", err, errCommandFailed)
+	}
+
+	defer watcher.Close()
+
+	update(entrypoints)
+
+	for {
+		err = watcher.SetFiles(dependencies.files())
+		if err != nil {
+			return fmt.Errorf("failed to watch: %w: %w", err, errCommandFailed)
+		}
+
+		changed, err := watcher.Next(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to watch: %w: %w", err, errCommandFailed)
+		}
+
+		affected := dependencies.affected(entrypoints, changed)
+		if len(affected) == 0 {
+			continue
+		}
+
+		for _, f := range changed {
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "changed %s\n", f)
+		}
+
+		update(affected)
+	}
+}
+
+// entrypointWatcher renders entrypoints, then renders them again whenever
+// a file they depend upon changes.
+type entrypointWatcher struct {
+	cmd      *cobra.Command
+	debounce time.Duration
+	options  render.Options
+	// depfile is the path of the depfile to write after each render, if any
+	depfile string
+
+	// newBuilder returns a new VM builder, so that changed files are not served from the importer's cache
+	newBuilder func() (*jsonnetvm.Builder, error)
+	// render evaluates entrypoints, returning the rendered files
+	render func(builder *jsonnetvm.Builder, entrypoints []string) ([]*render.File, error)
+	// validate validates the rendered files against any schemas, before headers are applied
+	validate func(files []*render.File) error
+	// write writes the rendered files, including headers
+	write func(files []*render.File) (bool, error)
+
+	dependencies dependencyGraph
+	// outputs are the paths of the files last generated by each entrypoint, keyed on entrypoint
+	outputs map[string][]string
+}
+
+// run renders every entrypoint, then renders the affected entrypoints whenever a file changes, until interrupted.
+func (w *entrypointWatcher) run(entrypoints []string) error {
+	w.dependencies = dependencyGraph{}
+	w.outputs = map[string][]string{}
+
+	return watchEntrypoints(w.cmd, w.debounce, entrypoints, w.dependencies, w.update)
+}
+
+// update renders the entrypoints and prints a summary of the changed outputs. Failures are
+// reported, rather than returned, so that watching continues until the failure is fixed.
+func (w *entrypointWatcher) update(entrypoints []string) {
+	start := time.Now()
+
+	err := w.renderEntrypoints(entrypoints)
+	if err != nil {
+		_, _ = fmt.Fprintf(w.cmd.ErrOrStderr(), "error: %v\n", err)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w.cmd.ErrOrStderr(), "rendered %d entrypoints in %v, watching %d files\n",
+		len(entrypoints), time.Since(start).Round(time.Millisecond), len(w.dependencies.files()))
+}
+
+func (w *entrypointWatcher) renderEntrypoints(entrypoints []string) error {
+	// The entrypoints and their project files are watched before the VM is built,
+	// so that watching continues if building it fails
+	seeds := map[string][]string{}
+
+	for _, entrypoint := range entrypoints {
+		files, err := projectFiles(entrypoint)
+		if err != nil {
+			return err
+		}
+
+		seeds[entrypoint] = files
+		w.dependencies.add(entrypoint, files...)
+	}
+
+	builder, err := w.newBuilder()
+	if err != nil {
+		return err
+	}
+
+	// Dependencies are found before rendering, so that an entrypoint which
+	// fails to render is still watched
+	vm := builder.MakeVM()
+
+	for _, entrypoint := range entrypoints {
+		deps, err := jsonnetvm.FindDependencies(vm, entrypoint)
+
+		err = w.dependencies.set(entrypoint, append(deps, seeds[entrypoint]...), err)
+		if err != nil {
+			return err
+		}
+	}
+
+	files, err := w.render(builder, entrypoints)
+	if err != nil {
+		return err
+	}
+
+	err = render.CheckDuplicatePaths(append(w.otherOutputs(entrypoints), files...))
+	if err != nil {
+		return fmt.Errorf("conflicting outputs: %w", err)
+	}
+
+	err = w.validate(files)
+	if err != nil {
+		return err
+	}
+
+	files, err = render.ApplyHeaders(files, w.options, w.cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to apply headers: %w", err)
+	}
+
+	changes := w.changes(entrypoints, files)
+
+	written, err := w.write(files)
+	if err != nil || !written {
+		return err
+	}
+
+	// Outputs are only recorded once written, so that a failed write is reported again by the next render
+	w.recordOutputs(entrypoints, files)
+
+	for _, change := range changes {
+		_, _ = fmt.Fprintln(w.cmd.OutOrStdout(), change)
+	}
+
+	if w.depfile != "" {
+		err = render.WriteDepfile(w.depfile, w.outputs, w.dependencies)
+		if err != nil {
+			return fmt.Errorf("failed to write depfile: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// otherOutputs returns the files last generated by every entrypoint other than entrypoints, so
+// that rendered files can be checked for conflicts with the outputs of entrypoints not rendered again.
+func (w *entrypointWatcher) otherOutputs(entrypoints []string) []*render.File {
+	var files []*render.File
+
+	for entrypoint, paths := range w.outputs {
+		if slices.Contains(entrypoints, entrypoint) {
+			continue
+		}
+
+		for _, filePath := range paths {
+			files = append(files, &render.File{Path: filePath, Source: entrypoint})
+		}
+	}
+
+	return files
+}
+
+// changes compares the rendered files against the files on disk, returning a line for each added, updated
+// or no longer generated file.
+func (w *entrypointWatcher) changes(entrypoints []string, files []*render.File) []string {
+	var changes []string
+
+	generated := map[string]bool{}
+
+	for _, file := range files {
+		generated[file.Path] = true
+
+		existing, err := os.ReadFile(file.Path)
+
+		switch {
+		case err != nil:
+			changes = append(changes, "added "+file.Path)
+		case !bytes.Equal(existing, file.Content):
+			changes = append(changes, "updated "+file.Path)
+		}
+	}
+
+	for _, entrypoint := range entrypoints {
+		for _, filePath := range w.outputs[entrypoint] {
+			if !generated[filePath] {
+				changes = append(changes, "no longer generated "+filePath)
+			}
+		}
+	}
+
+	slices.Sort(changes)
+
+	return changes
+}
+
+// recordOutputs records the files generated by each of entrypoints.
+func (w *entrypointWatcher) recordOutputs(entrypoints []string, files []*render.File) {
+	for _, entrypoint := range entrypoints {
+		w.outputs[entrypoint] = nil
+	}
+
+	for _, file := range files {
+		w.outputs[file.Source] = append(w.outputs[file.Source], file.Path)
+	}
+}
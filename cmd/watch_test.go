@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	t.Parallel()
+
+	lib, err := filepath.Abs("lib.libsonnet")
+	require.NoError(t, err)
+
+	fixture, err := filepath.Abs("fixture.json")
+	require.NoError(t, err)
+
+	entrypoint, err := filepath.Abs("b.jsonnet")
+	require.NoError(t, err)
+
+	graph := dependencyGraph{}
+	require.NoError(t, graph.set("a.jsonnet", []string{fixture, lib}, nil))
+	require.NoError(t, graph.set("b.jsonnet", []string{lib}, nil))
+
+	assert.Equal(t, []string{"a.jsonnet", "b.jsonnet"}, graph.affected([]string{"a.jsonnet", "b.jsonnet"}, []string{lib}))
+	assert.Equal(t, []string{"a.jsonnet"}, graph.affected([]string{"a.jsonnet", "b.jsonnet"}, []string{fixture}))
+	assert.Empty(t, graph.affected([]string{"a.jsonnet", "b.jsonnet"}, []string{"/elsewhere.libsonnet"}))
+
+	// When dependencies cannot be found, the previous dependencies are retained along with the entrypoint
+	require.NoError(t, graph.set("b.jsonnet", nil, errors.New("syntax error")))
+	assert.Equal(t, []string{entrypoint, lib}, graph["b.jsonnet"])
+
+	assert.Equal(t, []string{entrypoint, fixture, lib}, graph.files())
+}
+
+// TestEntrypointWatcherRenderEntrypoints renders entrypoints as watch mode would after each change,
+// using a fake render function, and verifies the reported changes and conflict detection.
+func TestEntrypointWatcherRenderEntrypoints(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.jsonnet")
+	b := filepath.Join(dir, "b.jsonnet")
+
+	for _, entrypoint := range []string{a, b} {
+		require.NoError(t, os.WriteFile(entrypoint, []byte("{}"), 0644))
+	}
+
+	options := render.Options{MultiDir: filepath.Join(dir, "output")}
+
+	// outputs are the files rendered by each entrypoint, keyed on file name
+	outputs := map[string]map[string]string{
+		a: {"a.yaml": "a: 1\n", "shared.yaml": "a: 1\n"},
+		b: {"b.yaml": "b: 1\n"},
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := &cobra.Command{}
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+
+	// writeErr is returned by the fake write function, when set
+	var writeErr error
+
+	w := &entrypointWatcher{
+		cmd:     cmd,
+		options: options,
+		newBuilder: func() (*jsonnetvm.Builder, error) {
+			return (&jsonnetvm.Options{}).NewBuilder(nil, &stderr)
+		},
+		render: func(_ *jsonnetvm.Builder, entrypoints []string) ([]*render.File, error) {
+			var files []*render.File
+
+			for _, entrypoint := range entrypoints {
+				for name, content := range outputs[entrypoint] {
+					files = append(files, &render.File{
+						Path:    filepath.Join(options.MultiDir, name),
+						Content: []byte(content),
+						Source:  entrypoint,
+						Format:  render.FormatYAML,
+					})
+				}
+			}
+
+			return files, nil
+		},
+		validate: func([]*render.File) error { return nil },
+		write: func(files []*render.File) (bool, error) {
+			if writeErr != nil {
+				return false, writeErr
+			}
+
+			return true, render.WriteFiles(files, options)
+		},
+		dependencies: dependencyGraph{},
+		outputs:      map[string][]string{},
+	}
+
+	output := func(name string) string {
+		return filepath.Join(options.MultiDir, name)
+	}
+
+	require.NoError(t, w.renderEntrypoints([]string{a, b}))
+	assert.Equal(t, "added "+output("a.yaml")+"\nadded "+output("b.yaml")+"\nadded "+output("shared.yaml")+"\n", stdout.String())
+
+	// Only the changed entrypoint is rendered again
+	stdout.Reset()
+
+	outputs[a] = map[string]string{"a.yaml": "a: 2\n"}
+
+	require.NoError(t, w.renderEntrypoints([]string{a}))
+	assert.Equal(t, "no longer generated "+output("shared.yaml")+"\nupdated "+output("a.yaml")+"\n", stdout.String())
+
+	// Outputs of entrypoints which were not rendered again are still checked for conflicts
+	stdout.Reset()
+
+	outputs[a] = map[string]string{"a.yaml": "a: 2\n", "b.yaml": "a: 2\n"}
+
+	err := w.renderEntrypoints([]string{a})
+	require.ErrorContains(t, err, output("b.yaml")+" is generated by both "+b+" and "+a)
+	assert.Empty(t, stdout.String())
+
+	content, err := os.ReadFile(output("b.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "b: 1\n", string(content))
+
+	// Outputs are not recorded when writing fails, so the changes are reported once written
+	outputs[a] = map[string]string{"c.yaml": "a: 3\n"}
+	writeErr = errors.New("disk full")
+
+	require.ErrorIs(t, w.renderEntrypoints([]string{a}), writeErr)
+	assert.Empty(t, stdout.String())
+
+	writeErr = nil
+
+	require.NoError(t, w.renderEntrypoints([]string{a}))
+	assert.Equal(t, "added "+output("c.yaml")+"\nno longer generated "+output("a.yaml")+"\n", stdout.String())
+}
+
+// TestEntrypointWatcherInvalidProject verifies that when the VM cannot be built, because the
+// jsonnetfile is invalid, the entrypoint and jsonnetfile are watched so that fixing it renders again.
+func TestEntrypointWatcherInvalidProject(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	entrypoint := filepath.Join(dir, "a.jsonnet")
+	jsonnetFile := filepath.Join(dir, "jsonnetfile.json")
+
+	require.NoError(t, os.WriteFile(entrypoint, []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(jsonnetFile, []byte("{"), 0644))
+
+	var stderr bytes.Buffer
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(&stderr)
+
+	w := &entrypointWatcher{
+		cmd: cmd,
+		newBuilder: func() (*jsonnetvm.Builder, error) {
+			return (&jsonnetvm.Options{JsonnetBundler: true}).NewBuilder([]string{entrypoint}, &stderr)
+		},
+		dependencies: dependencyGraph{},
+		outputs:      map[string][]string{},
+	}
+
+	w.update([]string{entrypoint})
+	assert.Contains(t, stderr.String(), "error: failed to load jsonnet-bundler project")
+
+	assert.Equal(t, []string{entrypoint}, w.dependencies.affected([]string{entrypoint}, []string{jsonnetFile}))
+	assert.Equal(t, []string{entrypoint, jsonnetFile, filepath.Join(dir, "jsonnetfile.lock.json")}, w.dependencies.files())
+}
@@ -3,88 +3,111 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"strings"
 
-	"github.com/fatih/color"
 	jsonnet "github.com/google/go-jsonnet"
 	"github.com/spf13/cobra"
 
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/jsonnetvm"
 	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
 )
 
 var errCommandFailed = errors.New("command failed")
 
-var (
-	yamlCommandJPaths        []string
-	yamlCommandRenderOptions render.Options
-	yamlCommandExtVars       map[string]string
-	yamlCommandExtCode       map[string]string
-)
-
-func init() {
-	rootCmd.AddCommand(yamlCommand)
-	yamlCommand.PersistentFlags().StringArrayVarP(
-		&yamlCommandJPaths, "jpath", "J", nil,
-		"Specify an additional library search dir",
-	)
-	yamlCommand.PersistentFlags().StringArrayVarP(
-		&yamlCommandRenderOptions.PriorityKeys, "priority-keys", "P", nil,
-		"Order these keys first in YAML output",
-	)
-	yamlCommand.PersistentFlags().StringVarP(
-		&yamlCommandRenderOptions.MultiDir, "multi", "m", ".",
-		"Write multiple files to the directory, list files on stdout",
-	)
-	yamlCommand.PersistentFlags().StringVarP(
-		&yamlCommandRenderOptions.Header, "header", "H", "",
-		"Write header to each file",
-	)
-	yamlCommand.PersistentFlags().StringVarP(
-		&yamlCommandRenderOptions.FilenamePrefix, "prefix", "p", "",
-		"Prefix to append to every emitted file",
-	)
-	yamlCommand.PersistentFlags().StringToStringVarP(
-		&yamlCommandExtVars, "ext-str", "V", map[string]string{},
-		"Provide an external value as a string to jsonnet",
-	)
-	yamlCommand.PersistentFlags().StringToStringVarP(
-		&yamlCommandExtCode, "ext-code", "C", map[string]string{},
-		"Provide an external value as a Jsonnet code to jsonnet",
-	)
+type yamlCommand struct {
+	vmOptions     jsonnetvm.Options
+	vmBuilder     *jsonnetvm.Builder
+	renderOptions render.Options
+	outputFlags
+	entrypointEvaluator
 }
 
-var yamlCommand = &cobra.Command{
-	Use:   "yaml",
-	Short: "Generate YAML from Jsonnet",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		vm := jsonnet.MakeVM()
-		for k, v := range yamlCommandExtVars {
-			vm.ExtVar(k, v)
-		}
-		for k, v := range yamlCommandExtCode {
-			vm.ExtCode(k, v)
-		}
+func (c *yamlCommand) makeVM() *jsonnet.VM {
+	vm := c.vmBuilder.MakeVM()
+	vm.StringOutput = true
 
-		vm.ErrorFormatter.SetColorFormatter(color.New(color.FgRed).Fprintf)
-		vm.StringOutput = true
+	return vm
+}
 
-		vm.Importer(&jsonnet.FileImporter{
-			JPaths: yamlCommandJPaths,
-		})
+func (c *yamlCommand) evaluate(vm *jsonnet.VM, entrypoint string) ([]*render.File, error) {
+	outputs, err := vm.EvaluateFileMulti(entrypoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate jsonnet: %w: %w", err, errCommandFailed)
+	}
 
-		files, err := vm.EvaluateFileMulti(args[0])
+	files := make([]*render.File, 0, len(outputs))
+
+	for k, data := range outputs {
+		file, err := render.YAMLStringData(k, data, c.renderOptions)
 		if err != nil {
-			return fmt.Errorf("failed to evaluate jsonnet: %w: %w", err, errCommandFailed)
+			return nil, fmt.Errorf("failed to write data: %w: %w", err, errCommandFailed)
 		}
 
-		for k, data := range files {
-			err = render.YAMLStringData(k, data, yamlCommandRenderOptions)
-			if err != nil {
-				return fmt.Errorf("failed to write data: %w: %w", err, errCommandFailed)
-			}
+		file.Source = entrypoint
+		files = append(files, file)
+	}
 
-		}
+	return files, nil
+}
+
+func (c *yamlCommand) RunE(cmd *cobra.Command, args []string) error {
+	err := c.outputFlags.validate(c.renderOptions)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	},
+	cmd.SilenceUsage = true
+
+	c.renderOptions.Version = toolVersion()
+
+	c.vmBuilder, err = c.vmOptions.NewBuilder(args, cmd.ErrOrStderr())
+	if err != nil {
+		return fmt.Errorf("failed to configure jsonnet: %w: %w", err, errCommandFailed)
+	}
+
+	files, err := c.evaluateEntrypoints(args, c.makeVM, c.evaluate)
+	if err != nil {
+		return err
+	}
+
+	return c.emitFiles(cmd, files, c.renderOptions, c.vmBuilder.MakeVM)
+}
+
+func NewYAMLCommand() *cobra.Command {
+	c := &yamlCommand{}
+
+	command := &cobra.Command{
+		Use:   "yaml [flags] <file>...",
+		Short: "Generate YAML from Jsonnet",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  c.RunE,
+	}
+
+	c.vmOptions.AddFlags(command.PersistentFlags())
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.MultiDir, "multi", "m", ".",
+		"Write multiple files to the directory, list files on stdout",
+	)
+	_ = command.MarkPersistentFlagDirname("multi")
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.Header, "header", "H", "",
+		"Write header to each file, as a comment. Supports the {{.Source}}, {{.Path}}, {{.Version}} and {{.Hash}} placeholders",
+	)
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.HeaderPolicy, "header-policy", "", render.HeaderPolicyWarn,
+		fmt.Sprintf("Header handling for files which cannot carry comments, such as JSON, one of: %s", strings.Join(render.HeaderPolicies, ", ")),
+	)
+	command.PersistentFlags().StringVarP(
+		&c.renderOptions.FilenamePrefix, "prefix", "p", "",
+		"Prefix to append to every emitted file",
+	)
+	addYAMLFlags(command.PersistentFlags(), &c.renderOptions)
+	c.outputFlags.addFlags(command.PersistentFlags())
+	c.entrypointEvaluator.addFlags(command.PersistentFlags())
+
+	return command
+}
+
+func init() {
+	rootCmd.AddCommand(NewYAMLCommand())
 }
@@ -0,0 +1,236 @@
+// Package bundler detects jsonnet-bundler projects, providing the library search paths and
+// legacy import aliases which jsonnet-bundler expects.
+package bundler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// JsonnetFile is the name of the jsonnet-bundler project file.
+	JsonnetFile = "jsonnetfile.json"
+	// LockFile is the name of the jsonnet-bundler lock file.
+	LockFile = "jsonnetfile.lock.json"
+	// VendorDir is the name of the directory into which jsonnet-bundler installs packages.
+	VendorDir = "vendor"
+)
+
+// Project is a jsonnet-bundler project.
+type Project struct {
+	// Dir is the directory containing the project's jsonnetfile.
+	Dir string
+	// Packages are the packages the project depends upon, from the lock file if present.
+	Packages []Package
+	// LegacyImports is true when packages may be imported using their legacy names.
+	LegacyImports bool
+}
+
+// Package is a package installed by jsonnet-bundler.
+type Package struct {
+	// Name is the path of the package within the vendor directory, for example github.com/grafana/grafonnet-lib/grafonnet.
+	Name string
+	// LegacyName is the short name the package may be imported as, for example grafonnet.
+	LegacyName string
+}
+
+type jsonnetFile struct {
+	Dependencies  []dependency `json:"dependencies"`
+	LegacyImports *bool        `json:"legacyImports"`
+}
+
+type dependency struct {
+	Source struct {
+		Git *struct {
+			Remote string `json:"remote"`
+			Subdir string `json:"subdir"`
+		} `json:"git"`
+		Local *struct {
+			Directory string `json:"directory"`
+		} `json:"local"`
+	} `json:"source"`
+	LegacyName string `json:"name"`
+}
+
+// Find searches dir and each of its parents for a jsonnet-bundler project, returning
+// nil if dir is not within a project.
+func Find(dir string) (*Project, error) {
+	dir, err := FindDir(dir)
+	if err != nil || dir == "" {
+		return nil, err
+	}
+
+	return Load(dir)
+}
+
+// FindDir searches dir and each of its parents for a jsonnetfile, returning the absolute
+// path of the directory containing it, or "" if dir is not within a project. Unlike Find,
+// the jsonnetfile is not read, so that the project is found even when it is invalid.
+func FindDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		_, err := os.Stat(filepath.Join(dir, JsonnetFile))
+		if err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// Load loads the jsonnet-bundler project in dir, returning nil if dir does not contain
+// a jsonnetfile. Packages are read from the lock file when present, as it lists every
+// transitive dependency.
+func Load(dir string) (*Project, error) {
+	project, err := readJsonnetFile(filepath.Join(dir, JsonnetFile))
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	lock, err := readJsonnetFile(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if lock != nil {
+		project.Dependencies = lock.Dependencies
+	}
+
+	legacyImports := true
+	if project.LegacyImports != nil {
+		legacyImports = *project.LegacyImports
+	}
+
+	p := &Project{Dir: dir, LegacyImports: legacyImports}
+
+	for _, d := range project.Dependencies {
+		pkg, ok := d.pkg()
+		if ok {
+			p.Packages = append(p.Packages, pkg)
+		}
+	}
+
+	return p, nil
+}
+
+func readJsonnetFile(fileName string) (*jsonnetFile, error) {
+	content, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jsonnetfile: %w", err)
+	}
+
+	f := &jsonnetFile{}
+
+	err = json.Unmarshal(content, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jsonnetfile: %s: %w", fileName, err)
+	}
+
+	return f, nil
+}
+
+// VendorDir returns the directory into which the project's packages are installed.
+func (p *Project) VendorDir() string {
+	return filepath.Join(p.Dir, VendorDir)
+}
+
+// Aliases returns the legacy import aliases for the project, mapping the legacy name of
+// each package to its name, or nil if the project does not use legacy imports.
+func (p *Project) Aliases() map[string]string {
+	if !p.LegacyImports {
+		return nil
+	}
+
+	aliases := map[string]string{}
+
+	for _, pkg := range p.Packages {
+		if pkg.LegacyName != "" && pkg.LegacyName != pkg.Name {
+			aliases[pkg.LegacyName] = pkg.Name
+		}
+	}
+
+	return aliases
+}
+
+// Missing returns the names of packages which have not been installed into the vendor directory.
+func (p *Project) Missing() []string {
+	var missing []string
+
+	for _, pkg := range p.Packages {
+		_, err := os.Stat(filepath.Join(p.VendorDir(), filepath.FromSlash(pkg.Name)))
+		if err != nil {
+			missing = append(missing, pkg.Name)
+		}
+	}
+
+	slices.Sort(missing)
+
+	return missing
+}
+
+// pkg returns the package installed for the dependency, using the same naming as jsonnet-bundler.
+func (d dependency) pkg() (Package, bool) {
+	switch {
+	case d.Source.Git != nil:
+		repo := gitRepoPath(d.Source.Git.Remote)
+		name := path.Join(repo, strings.Trim(d.Source.Git.Subdir, "/"))
+
+		return Package{Name: name, LegacyName: d.legacyName(name)}, true
+	case d.Source.Local != nil:
+		name := path.Base(filepath.ToSlash(d.Source.Local.Directory))
+
+		return Package{Name: name, LegacyName: d.legacyName(name)}, true
+	default:
+		return Package{}, false
+	}
+}
+
+func (d dependency) legacyName(name string) string {
+	if d.LegacyName != "" {
+		return d.LegacyName
+	}
+
+	return path.Base(name)
+}
+
+// gitRepoPath converts a git remote, such as https://github.com/grafana/grafonnet-lib.git or
+// git@github.com:grafana/grafonnet-lib.git, into a path such as github.com/grafana/grafonnet-lib.
+func gitRepoPath(remote string) string {
+	p := remote
+
+	scheme := strings.Index(p, "://")
+	if scheme >= 0 {
+		p = p[scheme+len("://"):]
+	} else if host, repo, ok := strings.Cut(p, ":"); ok {
+		// scp-like syntax, such as git@github.com:grafana/grafonnet-lib.git
+		p = host + "/" + repo
+	}
+
+	// Remove any user, such as git@
+	if at := strings.Index(p, "@"); at >= 0 && at < strings.Index(p+"/", "/") {
+		p = p[at+1:]
+	}
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+
+	return p
+}
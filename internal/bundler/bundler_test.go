@@ -0,0 +1,107 @@
+package bundler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJsonnetFile = `{
+  "version": 1,
+  "dependencies": [
+    {
+      "source": { "git": { "remote": "https://github.com/grafana/grafonnet-lib.git", "subdir": "grafonnet" } },
+      "version": "master"
+    }
+  ],
+  "legacyImports": true
+}`
+
+const testLockFile = `{
+  "version": 1,
+  "dependencies": [
+    {
+      "source": { "git": { "remote": "https://github.com/grafana/grafonnet-lib.git", "subdir": "grafonnet" } },
+      "version": "3082bfca110166cd69533fa3c0875fdb1b68c329",
+      "sum": "4/sUV0Kk+o8I+wlYxL9R6EPhL/NiLfYHk+NXlU64RUk="
+    },
+    {
+      "source": { "git": { "remote": "git@gitlab.com:gitlab-com/runbooks.git", "subdir": "libsonnet/ksonnet" } },
+      "version": "main",
+      "name": "ksonnet-legacy"
+    },
+    {
+      "source": { "local": { "directory": "../shared" } },
+      "version": ""
+    }
+  ],
+  "legacyImports": false
+}`
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, JsonnetFile), []byte(testJsonnetFile), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "environments", "gprd"), 0o755))
+
+	project, err := Find(filepath.Join(dir, "environments", "gprd"))
+	require.NoError(t, err)
+	require.NotNil(t, project)
+
+	assert.Equal(t, dir, project.Dir)
+	assert.Equal(t, filepath.Join(dir, "vendor"), project.VendorDir())
+	assert.Equal(t, map[string]string{"grafonnet": "github.com/grafana/grafonnet-lib/grafonnet"}, project.Aliases())
+	assert.Equal(t, []string{"github.com/grafana/grafonnet-lib/grafonnet"}, project.Missing())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor", "github.com", "grafana", "grafonnet-lib", "grafonnet"), 0o755))
+	assert.Empty(t, project.Missing())
+
+	project, err = Find(t.TempDir())
+	require.NoError(t, err)
+	assert.Nil(t, project)
+}
+
+func TestLoadLockFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, JsonnetFile), []byte(testJsonnetFile), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, LockFile), []byte(testLockFile), 0o600))
+
+	project, err := Load(dir)
+	require.NoError(t, err)
+
+	// legacyImports is read from the jsonnetfile, and packages from the lock file
+	assert.True(t, project.LegacyImports)
+	assert.Equal(t, []Package{
+		{Name: "github.com/grafana/grafonnet-lib/grafonnet", LegacyName: "grafonnet"},
+		{Name: "gitlab.com/gitlab-com/runbooks/libsonnet/ksonnet", LegacyName: "ksonnet-legacy"},
+		{Name: "shared", LegacyName: "shared"},
+	}, project.Packages)
+}
+
+func TestGitRepoPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{remote: "https://github.com/grafana/grafonnet-lib.git", want: "github.com/grafana/grafonnet-lib"},
+		{remote: "https://github.com/grafana/grafonnet-lib", want: "github.com/grafana/grafonnet-lib"},
+		{remote: "git@github.com:grafana/grafonnet-lib.git", want: "github.com/grafana/grafonnet-lib"},
+		{remote: "ssh://git@gitlab.com/gitlab-com/runbooks.git", want: "gitlab.com/gitlab-com/runbooks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, gitRepoPath(tt.remote))
+		})
+	}
+}
@@ -20,6 +20,13 @@ func recursivelyUpdateValue(v interface{}, priorityKeys []string) interface{} {
 	switch v2 := v.(type) {
 	case map[interface{}]interface{}:
 		return recursivelyUpdateMap(v2, priorityKeys)
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(v2))
+		for k, w := range v2 {
+			m[k] = w
+		}
+
+		return recursivelyUpdateMap(m, priorityKeys)
 	case yamlv2.MapSlice:
 		return recursivelyUpdateMapSlice(v2, priorityKeys)
 	case []interface{}:
@@ -113,3 +120,8 @@ func recursivelyUpdateMap(yaml map[interface{}]interface{}, priorityKeys []strin
 func ReorderKeys(yaml map[interface{}]interface{}, priorityKeys []string) yamlv2.MapSlice {
 	return recursivelyUpdateMap(yaml, priorityKeys)
 }
+
+// ReorderValue reorders the keys of every map within a value, prioritizing certain keys.
+func ReorderValue(v interface{}, priorityKeys []string) interface{} {
+	return recursivelyUpdateValue(v, priorityKeys)
+}
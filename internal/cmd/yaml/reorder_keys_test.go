@@ -21,6 +21,24 @@ func TestReorderKeys(t *testing.T) {
 			yaml: map[interface{}]interface{}{"hello": "there"},
 			want: yamlv2.MapSlice{yamlv2.MapItem{Key: "hello", Value: "there"}},
 		},
+		{
+			name: "nested_string_maps",
+			yaml: map[interface{}]interface{}{
+				"b": []interface{}{map[string]interface{}{"z": 1, "name": "x"}},
+				"a": map[string]interface{}{"y": 1, "name": "y"},
+			},
+			priorityKeys: []string{"name"},
+			want: yamlv2.MapSlice{
+				yamlv2.MapItem{Key: "a", Value: yamlv2.MapSlice{
+					yamlv2.MapItem{Key: "name", Value: "y"},
+					yamlv2.MapItem{Key: "y", Value: 1},
+				}},
+				yamlv2.MapItem{Key: "b", Value: []interface{}{yamlv2.MapSlice{
+					yamlv2.MapItem{Key: "name", Value: "x"},
+					yamlv2.MapItem{Key: "z", Value: 1},
+				}}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
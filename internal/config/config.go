@@ -0,0 +1,394 @@
+// Package config loads the project configuration file, which provides default flag values
+// for each command.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the name of the project configuration file.
+const FileName = ".jsonnet-tool.yaml"
+
+// DefaultsSection is the section of the configuration file which applies to every command.
+// Options in the defaults section are only applied to commands which have a flag of the same name.
+const DefaultsSection = "defaults"
+
+var errInvalidConfig = errors.New("invalid configuration")
+
+// Config is a project configuration file. Each section of the file is named after a command,
+// and sets default values for that command's flags, keyed on the flag name.
+//
+//	defaults:
+//	  jpath: [libsonnet, vendor]
+//	render:
+//	  priority-keys: [name, alert]
+//	  header: DO NOT EDIT
+type Config struct {
+	// Path is the path to the configuration file.
+	Path     string
+	sections map[string]map[string]interface{}
+}
+
+// Find searches dir and each of its parents for a configuration file, returning
+// its path, or an empty string if no configuration file is found.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("failed to check for configuration file: %w", err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// Load reads the configuration file at path.
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	sections := map[string]map[string]interface{}{}
+
+	err = yaml.UnmarshalStrict(content, &sections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %s: %w", path, err)
+	}
+
+	for name, options := range sections {
+		for k, v := range options {
+			options[k], err = normalizeValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s.%s: %w", path, name, k, err)
+			}
+		}
+	}
+
+	return &Config{Path: path, sections: sections}, nil
+}
+
+// Validate checks that each section of the configuration is either the defaults section or the name
+// of a command in commands, and that every option is a flag of the command, or in the case of the
+// defaults section, a flag of at least one command.
+func (c *Config) Validate(commands map[string]*pflag.FlagSet) error {
+	for _, name := range c.sectionNames() {
+		for _, k := range sortedKeys(c.sections[name]) {
+			if name == DefaultsSection {
+				if !anyHasFlag(commands, k) {
+					return fmt.Errorf("%s: %s.%s: unknown option: %w", c.Path, name, k, errInvalidConfig)
+				}
+
+				continue
+			}
+
+			flags, ok := commands[name]
+			if !ok {
+				return fmt.Errorf("%s: %s: unknown command: %w", c.Path, name, errInvalidConfig)
+			}
+
+			if flags.Lookup(k) == nil {
+				return fmt.Errorf("%s: %s.%s: unknown option: %w", c.Path, name, k, errInvalidConfig)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Options returns the options which apply to command, merging the defaults section with the
+// command's own section. Paths are resolved relative to the configuration file, for flags annotated
+// as file or directory names using cobra.Command.MarkFlagFilename or cobra.Command.MarkFlagDirname.
+func (c *Config) Options(command string, flags *pflag.FlagSet) map[string]interface{} {
+	options := map[string]interface{}{}
+
+	for _, name := range []string{DefaultsSection, command} {
+		for k, v := range c.sections[name] {
+			flag := flags.Lookup(k)
+			if flag == nil {
+				continue
+			}
+
+			options[k] = c.resolvePaths(flag, v)
+		}
+	}
+
+	return options
+}
+
+// Apply sets the flags of command from the configuration. Flags which have been set on the command
+// line take precedence over the configuration: list flags are merged, with the configured values
+// first, map flags are merged, with the command line's value for any key also in the configuration,
+// and other flags are left unchanged.
+func (c *Config) Apply(command string, flags *pflag.FlagSet) error {
+	options := c.Options(command, flags)
+
+	for _, k := range sortedKeys(options) {
+		flag := flags.Lookup(k)
+
+		args, err := flagArgs(flag, options[k])
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w: %w", c.Path, k, err, errInvalidConfig)
+		}
+
+		err = applyFlag(flags, flag, args)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w: %w", c.Path, k, err, errInvalidConfig)
+		}
+	}
+
+	return nil
+}
+
+// applyFlag sets a flag to the arguments from the configuration, unless it was given on the command
+// line, in which case the command line's values replace the configured values, including for lists and maps.
+func applyFlag(flags *pflag.FlagSet, flag *pflag.Flag, args []string) error {
+	if flag.Changed {
+		return nil
+	}
+
+	for _, arg := range args {
+		err := flags.Set(flag.Name, arg)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) sectionNames() []string {
+	names := make([]string, 0, len(c.sections))
+	for name := range c.sections {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// resolvePaths resolves any paths in value relative to the configuration file. For flags
+// given as key=value, such as --schema glob=schema.json, only the values are paths.
+func (c *Config) resolvePaths(flag *pflag.Flag, value interface{}) interface{} {
+	if !isPathFlag(flag) {
+		return value
+	}
+
+	dir := filepath.Dir(c.Path)
+
+	switch v := value.(type) {
+	case string:
+		// Only the value of a key=value argument is a path
+		if key, path, ok := strings.Cut(v, "="); ok && isKeyValueFlag(flag) {
+			return key + "=" + resolvePath(dir, path)
+		}
+
+		return resolvePath(dir, v)
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved[i] = c.resolvePaths(flag, item)
+		}
+
+		return resolved
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			// Map values are always paths, as the keys are given separately
+			if path, ok := item.(string); ok {
+				resolved[k] = resolvePath(dir, path)
+				continue
+			}
+
+			resolved[k] = c.resolvePaths(flag, item)
+		}
+
+		return resolved
+	default:
+		return value
+	}
+}
+
+// isPathFlag returns true for flags marked as file or directory names.
+func isPathFlag(flag *pflag.Flag) bool {
+	_, isFile := flag.Annotations[cobra.BashCompFilenameExt]
+	_, isDir := flag.Annotations[cobra.BashCompSubdirsInDir]
+
+	return isFile || isDir
+}
+
+// KeyValueAnnotation marks a path flag whose arguments are given as key=value, where only
+// the value is a path.
+const KeyValueAnnotation = "jsonnet-tool_key_value"
+
+// isKeyValueFlag returns true for flags whose arguments are key=value pairs.
+func isKeyValueFlag(flag *pflag.Flag) bool {
+	_, ok := flag.Annotations[KeyValueAnnotation]
+
+	return ok || flag.Value.Type() == stringToStringType
+}
+
+// resolvePath resolves path relative to dir. Where possible, the result is relative
+// to the working directory, so that paths reported by commands remain short.
+func resolvePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	path = filepath.Join(dir, path)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+
+	return rel
+}
+
+// stringToStringType is the type of pflag's map[string]string flags.
+const stringToStringType = "stringToString"
+
+// flagArgs converts an option value into the arguments which would be passed to the flag on the command line.
+// Lists are given as repeated flags, and maps as repeated key=value flags, one per entry.
+func flagArgs(flag *pflag.Flag, value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		args := make([]string, 0, len(v))
+
+		for _, item := range v {
+			itemArgs, err := flagArgs(flag, item)
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, itemArgs...)
+		}
+
+		return args, nil
+	case map[string]interface{}:
+		args := make([]string, 0, len(v))
+
+		for _, k := range sortedKeys(v) {
+			switch v[k].(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, fmt.Errorf("%s: expected a string, number or boolean value", k)
+			}
+
+			arg := k + "=" + fmt.Sprint(v[k])
+			if flag.Value.Type() == stringToStringType {
+				arg = quoteMapArg(arg)
+			}
+
+			args = append(args, arg)
+		}
+
+		return args, nil
+	case nil:
+		return nil, nil
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// quoteMapArg quotes a key=value argument for a pflag map flag, which parses arguments containing more
+// than one = as a CSV record, so that commas separate entries, and otherwise only trims any quotes at
+// either end. Arguments which either form would alter are written as a CSV record, repeating the entry
+// so that the record always contains more than one =.
+func quoteMapArg(arg string) string {
+	if strings.Count(arg, "=") == 1 && !strings.HasPrefix(arg, `"`) && !strings.HasSuffix(arg, `"`) {
+		return arg
+	}
+
+	quoted := `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+
+	return quoted + "," + quoted
+}
+
+// normalizeValue converts maps decoded by yaml.v2 to maps with string keys.
+func normalizeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			normalized, err := normalizeValue(item)
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = normalized
+		}
+
+		return v, nil
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+
+		for k, item := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected key type %T: %w", k, errInvalidConfig)
+			}
+
+			n, err := normalizeValue(item)
+			if err != nil {
+				return nil, err
+			}
+
+			normalized[key] = n
+		}
+
+		return normalized, nil
+	default:
+		return value, nil
+	}
+}
+
+func anyHasFlag(commands map[string]*pflag.FlagSet, name string) bool {
+	for _, flags := range commands {
+		if flags.Lookup(name) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
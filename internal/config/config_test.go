@@ -0,0 +1,222 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+defaults:
+  jpath: [lib, vendor]
+  ext-str:
+    env: gprd
+render:
+  ext-str:
+    list: a,b
+    quoted: '"x"'
+    equals: a=b,c
+    trailing: say "hi"
+  multi: out
+  priority-keys: [name, alert]
+  cache: false
+  schema: ['svc/*.yaml=schemas/svc.json']
+test:
+  cache: true
+  schema:
+    svc/*.yaml: schemas/svc.json
+`
+
+type testFlags struct {
+	jpaths       []string
+	extStr       map[string]string
+	multi        string
+	priorityKeys []string
+	cache        bool
+	schemas      []string
+}
+
+func newTestFlagSet(t *testing.T, values *testFlags) *pflag.FlagSet {
+	t.Helper()
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringArrayVarP(&values.jpaths, "jpath", "J", nil, "")
+	flags.StringToStringVarP(&values.extStr, "ext-str", "V", map[string]string{}, "")
+	flags.StringVar(&values.multi, "multi", ".", "")
+	flags.StringArrayVarP(&values.priorityKeys, "priority-keys", "P", nil, "")
+	flags.BoolVar(&values.cache, "cache", false, "")
+	flags.StringArrayVar(&values.schemas, "schema", nil, "")
+
+	require.NoError(t, cobra.MarkFlagDirname(flags, "jpath"))
+	require.NoError(t, cobra.MarkFlagDirname(flags, "multi"))
+	require.NoError(t, cobra.MarkFlagFilename(flags, "schema", "json"))
+	require.NoError(t, flags.SetAnnotation("schema", KeyValueAnnotation, []string{"true"}))
+
+	return flags
+}
+
+func writeTestConfig(t *testing.T, content string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	rel, err := filepath.Rel(wd, dir)
+	require.NoError(t, err)
+
+	return path, rel
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	path, _ := writeTestConfig(t, testConfig)
+	dir := filepath.Dir(path)
+
+	nested := filepath.Join(dir, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+
+	got, err := Find(nested)
+	require.NoError(t, err)
+	assert.Equal(t, path, got)
+
+	got, err = Find(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+// renderExtStr are the external variables configured for the render command, which replace those
+// of the defaults section.
+var renderExtStr = map[string]string{
+	"list": "a,b", "quoted": `"x"`, "equals": "a=b,c", "trailing": `say "hi"`,
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	path, dir := writeTestConfig(t, testConfig)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	// Only the schema half of each glob=schema.json argument is resolved
+	schemas := []string{"svc/*.yaml=" + filepath.Join(dir, "schemas", "svc.json")}
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		want    testFlags
+	}{
+		{
+			name:    "render",
+			command: "render",
+			want: testFlags{
+				jpaths:       []string{filepath.Join(dir, "lib"), filepath.Join(dir, "vendor")},
+				extStr:       renderExtStr,
+				multi:        filepath.Join(dir, "out"),
+				priorityKeys: []string{"name", "alert"},
+				schemas:      schemas,
+			},
+		},
+		{
+			name:    "command_line_precedence",
+			command: "render",
+			args:    []string{"-J", "other", "-V", "env=gstg", "-V", "extra=1", "--multi", "dir"},
+			want: testFlags{
+				jpaths:       []string{"other"},
+				extStr:       map[string]string{"env": "gstg", "extra": "1"},
+				multi:        "dir",
+				priorityKeys: []string{"name", "alert"},
+				schemas:      schemas,
+			},
+		},
+		{
+			name:    "defaults",
+			command: "test",
+			want: testFlags{
+				jpaths:  []string{filepath.Join(dir, "lib"), filepath.Join(dir, "vendor")},
+				extStr:  map[string]string{"env": "gprd"},
+				multi:   ".",
+				cache:   true,
+				schemas: schemas,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := testFlags{}
+			flags := newTestFlagSet(t, &got)
+			require.NoError(t, flags.Parse(tt.args))
+
+			require.NoError(t, cfg.Apply(tt.command, flags))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			config: testConfig,
+		},
+		{
+			name:    "unknown_command",
+			config:  "lint: {jpath: [lib]}",
+			wantErr: "lint: unknown command",
+		},
+		{
+			name:    "unknown_option",
+			config:  "render: {jpaths: [lib]}",
+			wantErr: "render.jpaths: unknown option",
+		},
+		{
+			name:    "unknown_default",
+			config:  "defaults: {jpaths: [lib]}",
+			wantErr: "defaults.jpaths: unknown option",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path, _ := writeTestConfig(t, tt.config)
+
+			cfg, err := Load(path)
+			require.NoError(t, err)
+
+			commands := map[string]*pflag.FlagSet{
+				"render": newTestFlagSet(t, &testFlags{}),
+				"test":   newTestFlagSet(t, &testFlags{}),
+			}
+
+			err = cfg.Validate(commands)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package deps builds the graph of imports between Jsonnet files.
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"github.com/google/go-jsonnet/toolutils"
+)
+
+// Kind is the kind of import.
+type Kind string
+
+const (
+	KindImport    Kind = "import"
+	KindImportStr Kind = "importstr"
+	KindImportBin Kind = "importbin"
+)
+
+// Edge is an import from one file to another.
+type Edge struct {
+	// Kind is the kind of import.
+	Kind Kind `json:"kind"`
+	// Import is the imported path, as written in the importing file.
+	Import string `json:"import"`
+	// Path is the path of the imported file, or empty if the import could not be resolved.
+	Path string `json:"path,omitempty"`
+	// Cycle is true when the import completes a cycle of imports.
+	Cycle bool `json:"cycle,omitempty"`
+	// Searched lists the directories searched for an unresolved import.
+	Searched []string `json:"searched,omitempty"`
+}
+
+// Unresolved returns true if the imported file could not be found.
+func (e *Edge) Unresolved() bool {
+	return e.Path == ""
+}
+
+// File is a file in the graph.
+type File struct {
+	// Imports are the imports made by the file, in the order they appear.
+	Imports []*Edge `json:"imports"`
+	// Error is set when the file could not be read or parsed.
+	Error string `json:"error,omitempty"`
+}
+
+// Graph is the graph of imports reachable from a set of root files.
+type Graph struct {
+	// Roots are the files from which the graph was built.
+	Roots []string `json:"roots"`
+	// Files holds every file in the graph, keyed on path.
+	Files map[string]*File `json:"files"`
+	// Cycles lists each cycle of imports, starting and ending with the same file.
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+// Build returns the import graph of the roots. Imports are resolved using importer, and jpaths are
+// the library search directories used by importer, which are reported for unresolved imports.
+func Build(roots []string, importer jsonnet.Importer, jpaths []string) *Graph {
+	b := &builder{
+		importer: importer,
+		jpaths:   jpaths,
+		graph:    &Graph{Files: map[string]*File{}},
+		state:    map[string]visitState{},
+	}
+
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		b.graph.Roots = append(b.graph.Roots, root)
+
+		b.visit(root, nil)
+	}
+
+	return b.graph
+}
+
+type visitState int
+
+const (
+	unvisited visitState = iota
+	visiting
+	visited
+)
+
+type builder struct {
+	importer jsonnet.Importer
+	jpaths   []string
+	graph    *Graph
+	state    map[string]visitState
+	stack    []string
+}
+
+// visit adds fileName and everything it imports to the graph, using a depth-first
+// search so that cycles can be detected. contents is nil for root files, which are
+// read from disk rather than through the importer.
+func (b *builder) visit(fileName string, contents *jsonnet.Contents) {
+	if b.state[fileName] != unvisited {
+		return
+	}
+
+	b.state[fileName] = visiting
+	b.stack = append(b.stack, fileName)
+
+	defer func() {
+		b.state[fileName] = visited
+		b.stack = b.stack[:len(b.stack)-1]
+	}()
+
+	file := &File{Imports: []*Edge{}}
+	b.graph.Files[fileName] = file
+
+	var source string
+
+	if contents == nil {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			file.Error = err.Error()
+			return
+		}
+
+		source = string(content)
+	} else {
+		source = contents.String()
+	}
+
+	node, err := jsonnet.SnippetToAST(fileName, source)
+	if err != nil {
+		file.Error = err.Error()
+		return
+	}
+
+	for _, edge := range findImports(node) {
+		file.Imports = append(file.Imports, edge)
+
+		imported, foundAt, err := b.importer.Import(fileName, edge.Import)
+		if err != nil {
+			edge.Searched = b.searched(fileName)
+			continue
+		}
+
+		edge.Path = filepath.Clean(foundAt)
+
+		// The content of importstr and importbin is not Jsonnet, so is not followed
+		if edge.Kind != KindImport {
+			continue
+		}
+
+		if b.state[edge.Path] == visiting {
+			edge.Cycle = true
+			b.recordCycle(edge.Path)
+
+			continue
+		}
+
+		b.visit(edge.Path, &imported)
+	}
+}
+
+// recordCycle records the cycle from fileName, which is on the stack, to the top of the stack.
+func (b *builder) recordCycle(fileName string) {
+	for i, f := range b.stack {
+		if f == fileName {
+			cycle := append([]string{}, b.stack[i:]...)
+			b.graph.Cycles = append(b.graph.Cycles, append(cycle, fileName))
+
+			return
+		}
+	}
+}
+
+// searched returns the directories searched for imports from fileName, in search order.
+func (b *builder) searched(fileName string) []string {
+	dir := filepath.Dir(fileName)
+
+	return append([]string{dir}, b.jpaths...)
+}
+
+// findImports returns every import in node, in source order.
+func findImports(node ast.Node) []*Edge {
+	var imports []ast.Node
+
+	collectImports(node, &imports)
+
+	slices.SortStableFunc(imports, func(a, b ast.Node) int {
+		return compareLocations(a.Loc().Begin, b.Loc().Begin)
+	})
+
+	edges := make([]*Edge, len(imports))
+
+	for i, n := range imports {
+		switch n := n.(type) {
+		case *ast.Import:
+			edges[i] = &Edge{Kind: KindImport, Import: n.File.Value}
+		case *ast.ImportStr:
+			edges[i] = &Edge{Kind: KindImportStr, Import: n.File.Value}
+		case *ast.ImportBin:
+			edges[i] = &Edge{Kind: KindImportBin, Import: n.File.Value}
+		}
+	}
+
+	return edges
+}
+
+// collectImports appends the import nodes within node to imports. The order is not
+// the source order, as toolutils.Children visits the body of a local before its binds.
+func collectImports(node ast.Node, imports *[]ast.Node) {
+	switch node.(type) {
+	case *ast.Import, *ast.ImportStr, *ast.ImportBin:
+		*imports = append(*imports, node)
+		return
+	}
+
+	for _, child := range toolutils.Children(node) {
+		if child != nil {
+			collectImports(child, imports)
+		}
+	}
+}
+
+func compareLocations(a, b ast.Location) int {
+	if a.Line != b.Line {
+		return a.Line - b.Line
+	}
+
+	return a.Column - b.Column
+}
@@ -0,0 +1,157 @@
+package deps
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFixtures writes a set of files with an import cycle, a diamond dependency, importstr
+// and importbin imports, an import resolved from a library directory and an unresolved import.
+func writeFixtures(t *testing.T) (string, []string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.jsonnet": `
+			local a = import 'a.libsonnet';
+			local lib = import 'lib.libsonnet';
+			{ a: a, lib: lib, text: importstr 'data.txt', bin: importbin 'data.bin', missing: import 'missing.libsonnet' }`,
+		"a.libsonnet":          "(import 'b.libsonnet') + (import 'c.libsonnet')",
+		"b.libsonnet":          "import 'c.libsonnet'",
+		"c.libsonnet":          "import 'a.libsonnet'",
+		"data.txt":             "text",
+		"data.bin":             "bin",
+		"vendor/lib.libsonnet": "{ broken: ",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	return dir, []string{filepath.Join(dir, "vendor")}
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	dir, jpaths := writeFixtures(t)
+	main := filepath.Join(dir, "main.jsonnet")
+
+	graph := Build([]string{main}, &jsonnet.FileImporter{JPaths: jpaths}, jpaths)
+
+	assert.Equal(t, []string{main}, graph.Roots)
+	assert.Len(t, graph.Files, 5)
+
+	imports := graph.Files[main].Imports
+	require.Len(t, imports, 5)
+	assert.Equal(t, &Edge{Kind: KindImport, Import: "a.libsonnet", Path: filepath.Join(dir, "a.libsonnet")}, imports[0])
+	assert.Equal(t, &Edge{Kind: KindImport, Import: "lib.libsonnet", Path: filepath.Join(dir, "vendor/lib.libsonnet")}, imports[1])
+	assert.Equal(t, &Edge{Kind: KindImportStr, Import: "data.txt", Path: filepath.Join(dir, "data.txt")}, imports[2])
+	assert.Equal(t, &Edge{Kind: KindImportBin, Import: "data.bin", Path: filepath.Join(dir, "data.bin")}, imports[3])
+	assert.Equal(t, &Edge{Kind: KindImport, Import: "missing.libsonnet", Searched: []string{dir, filepath.Join(dir, "vendor")}}, imports[4])
+
+	assert.NotEmpty(t, graph.Files[filepath.Join(dir, "vendor/lib.libsonnet")].Error)
+	assert.True(t, graph.Files[filepath.Join(dir, "c.libsonnet")].Imports[0].Cycle)
+	assert.Equal(t, [][]string{
+		{filepath.Join(dir, "a.libsonnet"), filepath.Join(dir, "b.libsonnet"), filepath.Join(dir, "c.libsonnet"), filepath.Join(dir, "a.libsonnet")},
+	}, graph.Cycles)
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	dir, jpaths := writeFixtures(t)
+
+	graph := Build([]string{filepath.Join(dir, "main.jsonnet")}, &jsonnet.FileImporter{JPaths: jpaths}, jpaths)
+
+	tests := []struct {
+		name         string
+		outputFormat string
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name:         "tree",
+			outputFormat: OutputFormatTree,
+			want: []string{
+				"DIR/main.jsonnet\n" +
+					"├── DIR/a.libsonnet\n" +
+					"│   ├── DIR/b.libsonnet\n" +
+					"│   │   └── DIR/c.libsonnet\n" +
+					"│   │       └── DIR/a.libsonnet (cycle)\n" +
+					"│   └── DIR/c.libsonnet (see above)\n" +
+					"├── DIR/vendor/lib.libsonnet (error: ",
+				"├── DIR/data.txt (importstr)\n" +
+					"├── DIR/data.bin (importbin)\n" +
+					`└── "missing.libsonnet" (unresolved, searched: DIR, DIR/vendor)` + "\n",
+			},
+		},
+		{
+			name:         "json",
+			outputFormat: OutputFormatJSON,
+			want: []string{
+				`"roots": [
+    "DIR/main.jsonnet"
+  ]`,
+				`{
+          "kind": "import",
+          "import": "missing.libsonnet",
+          "searched": [
+            "DIR",
+            "DIR/vendor"
+          ]
+        }`,
+				`"cycles": [`,
+			},
+		},
+		{
+			name:         "dot",
+			outputFormat: OutputFormatDOT,
+			want: []string{
+				"digraph deps {\n",
+				`"DIR/main.jsonnet" [shape=box];`,
+				`"DIR/main.jsonnet" -> "DIR/a.libsonnet";`,
+				`"DIR/main.jsonnet" -> "DIR/data.txt" [style=dashed, label=importstr];`,
+				`"DIR/main.jsonnet" -> "DIR/data.bin" [style=dotted, label=importbin];`,
+				`"unresolved:DIR/main.jsonnet:missing.libsonnet" [label="missing.libsonnet", color=red, shape=octagon, tooltip="searched: DIR, DIR/vendor"];`,
+				`"DIR/main.jsonnet" -> "unresolved:DIR/main.jsonnet:missing.libsonnet" [color=red];`,
+				`"DIR/c.libsonnet" -> "DIR/a.libsonnet" [color=red];`,
+			},
+		},
+		{
+			name:         "unknown",
+			outputFormat: "xml",
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			err := Write(&out, graph, tt.outputFormat)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got := strings.ReplaceAll(out.String(), dir, "DIR")
+			for _, want := range tt.want {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
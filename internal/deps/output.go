@@ -0,0 +1,207 @@
+package deps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var errOutputFailed = errors.New("output failed")
+
+const (
+	// OutputFormatTree prints the imports of each root as an indented tree.
+	OutputFormatTree = "tree"
+
+	// OutputFormatJSON emits the graph as a JSON adjacency list.
+	OutputFormatJSON = "json"
+
+	// OutputFormatDOT emits the graph in the Graphviz DOT language.
+	OutputFormatDOT = "dot"
+)
+
+// OutputFormats are the supported formats for Write.
+var OutputFormats = []string{OutputFormatTree, OutputFormatJSON, OutputFormatDOT}
+
+// Write writes the graph to w in the given output format.
+func Write(w io.Writer, graph *Graph, outputFormat string) error {
+	var err error
+
+	switch outputFormat {
+	case OutputFormatTree:
+		err = writeTree(w, graph)
+	case OutputFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(graph)
+	case OutputFormatDOT:
+		err = writeDOT(w, graph)
+	default:
+		return fmt.Errorf("unknown output format %q: %w", outputFormat, errOutputFailed)
+	}
+
+	if err != nil {
+		return fmt.Errorf("write failed: %w: %w", err, errOutputFailed)
+	}
+
+	return nil
+}
+
+// writeTree prints each root followed by its imports. Files which have already been
+// expanded are not expanded again, to keep the output bounded for diamond dependencies.
+func writeTree(w io.Writer, graph *Graph) error {
+	var b strings.Builder
+
+	expanded := map[string]bool{}
+
+	for _, root := range graph.Roots {
+		b.WriteString(root)
+		b.WriteString(fileAnnotation(graph.Files[root]))
+		b.WriteString("\n")
+
+		if !expanded[root] {
+			expanded[root] = true
+			writeTreeImports(&b, graph, root, "", expanded)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeTreeImports(b *strings.Builder, graph *Graph, fileName string, indent string, expanded map[string]bool) {
+	file := graph.Files[fileName]
+	if file == nil {
+		return
+	}
+
+	for i, edge := range file.Imports {
+		branch, childIndent := "├── ", "│   "
+		if i == len(file.Imports)-1 {
+			branch, childIndent = "└── ", "    "
+		}
+
+		var notes []string
+		if edge.Kind != KindImport {
+			notes = append(notes, string(edge.Kind))
+		}
+
+		b.WriteString(indent)
+		b.WriteString(branch)
+
+		switch {
+		case edge.Unresolved():
+			b.WriteString(strconv.Quote(edge.Import))
+
+			notes = append(notes, "unresolved, searched: "+strings.Join(edge.Searched, ", "))
+		case edge.Cycle:
+			b.WriteString(edge.Path)
+
+			notes = append(notes, "cycle")
+		default:
+			b.WriteString(edge.Path)
+
+			if edge.Kind == KindImport && expanded[edge.Path] && len(graph.Files[edge.Path].Imports) > 0 {
+				notes = append(notes, "see above")
+			}
+		}
+
+		if len(notes) > 0 {
+			b.WriteString(" (" + strings.Join(notes, "; ") + ")")
+		}
+
+		if edge.Kind == KindImport && !edge.Unresolved() {
+			b.WriteString(fileAnnotation(graph.Files[edge.Path]))
+		}
+
+		b.WriteString("\n")
+
+		if edge.Kind == KindImport && !edge.Unresolved() && !edge.Cycle && !expanded[edge.Path] {
+			expanded[edge.Path] = true
+			writeTreeImports(b, graph, edge.Path, indent+childIndent, expanded)
+		}
+	}
+}
+
+func fileAnnotation(file *File) string {
+	if file == nil || file.Error == "" {
+		return ""
+	}
+
+	return " (error: " + file.Error + ")"
+}
+
+// writeDOT emits the graph as a Graphviz digraph. Roots are drawn as boxes, importstr and
+// importbin edges are dashed and dotted, and cycles and unresolved imports are red.
+func writeDOT(w io.Writer, graph *Graph) error {
+	var b strings.Builder
+
+	b.WriteString("digraph deps {\n")
+	b.WriteString("  node [shape=ellipse];\n")
+
+	for _, root := range graph.Roots {
+		fmt.Fprintf(&b, "  %s [shape=box];\n", strconv.Quote(root))
+	}
+
+	fileNames := make([]string, 0, len(graph.Files))
+	for fileName := range graph.Files {
+		fileNames = append(fileNames, fileName)
+	}
+
+	slices.Sort(fileNames)
+
+	for _, fileName := range fileNames {
+		file := graph.Files[fileName]
+		if file.Error != "" {
+			fmt.Fprintf(&b, "  %s [color=red, tooltip=%s];\n", strconv.Quote(fileName), strconv.Quote(file.Error))
+		}
+
+		for _, edge := range file.Imports {
+			var attrs []string
+
+			switch edge.Kind {
+			case KindImportStr:
+				attrs = append(attrs, "style=dashed", "label=importstr")
+			case KindImportBin:
+				attrs = append(attrs, "style=dotted", "label=importbin")
+			case KindImport:
+			}
+
+			target := edge.Path
+
+			if edge.Unresolved() {
+				// Unresolved imports get a node per importing file, as the same path may resolve
+				// differently, or not at all, depending on where it is imported from.
+				target = "unresolved:" + fileName + ":" + edge.Import
+				fmt.Fprintf(&b, "  %s [label=%s, color=red, shape=octagon, tooltip=%s];\n",
+					strconv.Quote(target), strconv.Quote(edge.Import),
+					strconv.Quote("searched: "+strings.Join(edge.Searched, ", ")))
+
+				attrs = append(attrs, "color=red")
+			}
+
+			if edge.Cycle {
+				attrs = append(attrs, "color=red")
+			}
+
+			fmt.Fprintf(&b, "  %s -> %s", strconv.Quote(fileName), strconv.Quote(target))
+
+			if len(attrs) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+			}
+
+			b.WriteString(";\n")
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
@@ -0,0 +1,46 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+)
+
+// Pretty will generate a color-coded unified diff for display,
+// omitting the file header lines.
+func Pretty(fileName string, expected, actual string) string {
+	edits := myers.ComputeEdits(span.URIFromPath(fileName), expected, actual)
+	diff := fmt.Sprint(gotextdiff.ToUnified(fileName, fileName, expected, edits))
+
+	out := ""
+
+	// Remove the first two lines of the unified diff
+	count := 0
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+
+	for scanner.Scan() {
+		count = count + 1
+
+		if count > 3 {
+			out = out + "\n"
+		}
+
+		if count > 2 {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "-") {
+				out = out + color.RedString(scanner.Text())
+			} else if strings.HasPrefix(line, "+") {
+				out = out + color.YellowString(scanner.Text())
+			} else {
+				out = out + scanner.Text()
+			}
+		}
+	}
+
+	return out
+}
@@ -20,3 +20,15 @@ func Failed() *Error {
 func Invalid() *Error {
 	return &Error{ExitCode: 3, Msg: "invalid"}
 }
+
+// Outdated creates an Error instance with exitCode=4.
+// It is used when generated files do not match their sources.
+func Outdated() *Error {
+	return &Error{ExitCode: 4, Msg: "outdated"}
+}
+
+// SchemaViolation creates an Error instance with exitCode=5.
+// It is used when generated files do not conform to their JSON Schemas.
+func SchemaViolation() *Error {
+	return &Error{ExitCode: 5, Msg: "schema violation"}
+}
@@ -0,0 +1,184 @@
+// Package format formats Jsonnet source using the go-jsonnet formatter.
+package format
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/go-jsonnet/formatter"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/bundler"
+)
+
+var errFormatFailed = errors.New("format failed")
+
+// Extensions are the file extensions of the Jsonnet files found in directories.
+var Extensions = []string{".jsonnet", ".libsonnet"}
+
+var stringStyles = map[string]formatter.StringStyle{
+	"double": formatter.StringStyleDouble,
+	"single": formatter.StringStyleSingle,
+	"leave":  formatter.StringStyleLeave,
+}
+
+var commentStyles = map[string]formatter.CommentStyle{
+	"hash":  formatter.CommentStyleHash,
+	"slash": formatter.CommentStyleSlash,
+	"leave": formatter.CommentStyleLeave,
+}
+
+// Options configures the formatter. They are usually populated from command line flags using AddFlags,
+// and default to the go-jsonnet recommended style.
+type Options struct {
+	Indent           int
+	MaxBlankLines    int
+	StringStyle      string
+	CommentStyle     string
+	PrettyFieldNames bool
+	PadArrays        bool
+	PadObjects       bool
+	SortImports      bool
+	UseImplicitPlus  bool
+}
+
+// AddFlags registers flags for every formatter option on flags.
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	defaults := formatter.DefaultOptions()
+
+	flags.IntVarP(
+		&o.Indent, "indent", "n", defaults.Indent,
+		"Number of spaces to indent by, or 0 to leave indentation unchanged",
+	)
+	flags.IntVarP(
+		&o.MaxBlankLines, "max-blank-lines", "", defaults.MaxBlankLines,
+		"Maximum number of consecutive blank lines, or 0 to leave blank lines unchanged",
+	)
+	flags.StringVarP(
+		&o.StringStyle, "string-style", "", "single",
+		"Quotes used for strings, one of: double, single, leave",
+	)
+	flags.StringVarP(
+		&o.CommentStyle, "comment-style", "", "slash",
+		"Style used for comments, one of: hash, slash, leave. Shebangs are never changed",
+	)
+	flags.BoolVarP(
+		&o.PrettyFieldNames, "pretty-field-names", "", defaults.PrettyFieldNames,
+		"Only quote field names where required, and use dot syntax for indexing where possible",
+	)
+	flags.BoolVarP(
+		&o.PadArrays, "pad-arrays", "", defaults.PadArrays,
+		"Write arrays as [ 1, 2, 3 ] rather than [1, 2, 3]",
+	)
+	flags.BoolVarP(
+		&o.PadObjects, "pad-objects", "", defaults.PadObjects,
+		"Write objects as { x: 1 } rather than {x: 1}",
+	)
+	flags.BoolVarP(
+		&o.SortImports, "sort-imports", "", defaults.SortImports,
+		"Sort the imports at the top of each file",
+	)
+	flags.BoolVarP(
+		&o.UseImplicitPlus, "use-implicit-plus", "", defaults.UseImplicitPlus,
+		"Remove plus signs where they are not required",
+	)
+}
+
+// formatterOptions returns the go-jsonnet formatter options.
+func (o *Options) formatterOptions() (formatter.Options, error) {
+	stringStyle, ok := stringStyles[o.StringStyle]
+	if !ok {
+		return formatter.Options{}, fmt.Errorf("unknown string style %q: %w", o.StringStyle, errFormatFailed)
+	}
+
+	commentStyle, ok := commentStyles[o.CommentStyle]
+	if !ok {
+		return formatter.Options{}, fmt.Errorf("unknown comment style %q: %w", o.CommentStyle, errFormatFailed)
+	}
+
+	return formatter.Options{
+		Indent:           o.Indent,
+		MaxBlankLines:    o.MaxBlankLines,
+		StringStyle:      stringStyle,
+		CommentStyle:     commentStyle,
+		PrettyFieldNames: o.PrettyFieldNames,
+		PadArrays:        o.PadArrays,
+		PadObjects:       o.PadObjects,
+		SortImports:      o.SortImports,
+		UseImplicitPlus:  o.UseImplicitPlus,
+	}, nil
+}
+
+// Formatter formats Jsonnet source.
+type Formatter struct {
+	options formatter.Options
+}
+
+// NewFormatter returns a Formatter for the options, failing if any option is invalid.
+func (o *Options) NewFormatter() (*Formatter, error) {
+	options, err := o.formatterOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Formatter{options: options}, nil
+}
+
+// Format returns the formatted form of source, which was read from fileName.
+func (f *Formatter) Format(fileName string, source string) (string, error) {
+	formatted, err := formatter.Format(fileName, source, f.options)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", err, errFormatFailed)
+	}
+
+	return formatted, nil
+}
+
+// FindFiles returns the files given in paths, replacing each directory with the Jsonnet files it
+// contains, recursively, in lexical order. Hidden directories and jsonnet-bundler vendor directories
+// are skipped, as they are not usually under the project's control.
+func FindFiles(paths []string) ([]string, error) {
+	var files []string
+
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", err, errFormatFailed)
+		}
+
+		if !info.IsDir() {
+			files = append(files, root)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if filePath != root && (strings.HasPrefix(d.Name(), ".") || d.Name() == bundler.VendorDir) {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if slices.Contains(Extensions, filepath.Ext(filePath)) {
+				files = append(files, filePath)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to walk %s: %w: %w", root, err, errFormatFailed)
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,103 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatterFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "defaults",
+			source: `{"a": [1,2], b: "c"}`,
+			want:   "{ a: [1, 2], b: 'c' }\n",
+		},
+		{
+			name:   "options",
+			args:   []string{"--string-style", "double", "--pad-arrays", "--pad-objects=false", "--pretty-field-names=false"},
+			source: `{"a": [1,2], b: 'c'}`,
+			want:   "{\"a\": [ 1, 2 ], b: \"c\"}\n",
+		},
+		{
+			name:   "comment_style",
+			args:   []string{"--comment-style", "hash"},
+			source: "// comment\n{}\n",
+			want:   "# comment\n{}\n",
+		},
+		{
+			name:    "invalid_source",
+			source:  "{",
+			wantErr: true,
+		},
+		{
+			name:    "invalid_string_style",
+			args:    []string{"--string-style", "backtick"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var options Options
+
+			flags := pflag.NewFlagSet(tt.name, pflag.ContinueOnError)
+			options.AddFlags(flags)
+			require.NoError(t, flags.Parse(tt.args))
+
+			formatter, err := options.NewFormatter()
+			if err == nil {
+				var got string
+
+				got, err = formatter.Format("test.jsonnet", tt.source)
+				if !tt.wantErr {
+					assert.Equal(t, tt.want, got)
+				}
+			}
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a.jsonnet", "b.txt", "sub/c.libsonnet", "sub/d.json",
+		".git/e.jsonnet", "vendor/f.libsonnet", "sub/vendor/g.libsonnet",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600))
+	}
+
+	files, err := FindFiles([]string{dir, filepath.Join(dir, "vendor", "f.libsonnet")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.jsonnet"),
+		filepath.Join(dir, "sub", "c.libsonnet"),
+		filepath.Join(dir, "vendor", "f.libsonnet"),
+	}, files)
+
+	_, err = FindFiles([]string{filepath.Join(dir, "missing")})
+	require.Error(t, err)
+}
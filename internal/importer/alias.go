@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"strings"
+
+	jsonnet "github.com/google/go-jsonnet"
+)
+
+// Alias is a jsonnet.Importer which retries imports which could not be found, replacing
+// the first element of the imported path using aliases. This provides the legacy import
+// names used by jsonnet-bundler, such as `grafonnet/grafana.libsonnet` for
+// `github.com/grafana/grafonnet-lib/grafonnet/grafana.libsonnet`.
+type Alias struct {
+	importer jsonnet.Importer
+	aliases  map[string]string
+}
+
+var _ jsonnet.Importer = &Alias{}
+
+// Import resolves and reads an imported file using the wrapped importer.
+func (a *Alias) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	contents, foundAt, err := a.importer.Import(importedFrom, importedPath)
+	if err == nil {
+		return contents, foundAt, nil
+	}
+
+	first, rest, ok := strings.Cut(importedPath, "/")
+	if !ok {
+		// Errors are returned unwrapped, as they are reported verbatim by the VM
+		return contents, foundAt, err //nolint:wrapcheck
+	}
+
+	name, ok := a.aliases[first]
+	if !ok {
+		return contents, foundAt, err //nolint:wrapcheck
+	}
+
+	aliasContents, aliasFoundAt, aliasErr := a.importer.Import(importedFrom, name+"/"+rest)
+	if aliasErr != nil {
+		// Report the original import path, rather than the alias
+		return contents, foundAt, err //nolint:wrapcheck
+	}
+
+	return aliasContents, aliasFoundAt, nil
+}
+
+// NewAlias returns an importer wrapping importer, which retries imports using aliases,
+// keyed on the first element of the imported path.
+func NewAlias(importer jsonnet.Importer, aliases map[string]string) *Alias {
+	return &Alias{importer: importer, aliases: aliases}
+}
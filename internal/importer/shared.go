@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"path/filepath"
+	"sync"
+
+	jsonnet "github.com/google/go-jsonnet"
+)
+
+// Shared is a jsonnet.Importer which can safely be shared between VMs running in
+// different goroutines. It caches the result of every import, so that each imported
+// file is only resolved and read once, however many VMs import it.
+//
+// Only file contents are shared: each VM keeps its own cache of parsed files, keyed on
+// the import location, so reusing a VM for several evaluations avoids re-parsing
+// shared imports.
+type Shared struct {
+	newImporter func() jsonnet.Importer
+
+	// imports maps an importKey to its *sharedImport
+	imports sync.Map
+
+	// contents maps each import location to its contents, as VMs require the same
+	// Contents for every import of a location
+	contents sync.Map
+}
+
+var _ jsonnet.Importer = &Shared{}
+
+// importKey identifies an import. Imports are resolved relative to the directory of the
+// importing file, so imports of the same path from one directory resolve identically.
+type importKey struct {
+	dir  string
+	path string
+}
+
+// sharedImport is the result of an import, resolved once.
+type sharedImport struct {
+	once     sync.Once
+	contents jsonnet.Contents
+	foundAt  string
+	err      error
+}
+
+// Import resolves and reads an imported file, or returns the cached result of an earlier
+// import of the same path from the same directory. Imports which are not cached are resolved
+// concurrently, each using a new importer from newImporter.
+func (s *Shared) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	dir, _ := filepath.Split(importedFrom)
+	key := importKey{dir: dir, path: importedPath}
+
+	value, ok := s.imports.Load(key)
+	if !ok {
+		value, _ = s.imports.LoadOrStore(key, &sharedImport{})
+	}
+
+	result := value.(*sharedImport)
+
+	result.once.Do(func() {
+		result.contents, result.foundAt, result.err = s.newImporter().Import(importedFrom, importedPath)
+		if result.err != nil {
+			return
+		}
+
+		// Imports of the same location using different paths, such as relative and library
+		// paths, must return the same Contents
+		contents, _ := s.contents.LoadOrStore(result.foundAt, result.contents)
+		result.contents = contents.(jsonnet.Contents)
+	})
+
+	// Errors are returned unwrapped, as they are reported verbatim by the VM
+	return result.contents, result.foundAt, result.err
+}
+
+// NewShared returns an importer, safe for concurrent use, which resolves imports using
+// importers from newImporter. The importers need not be safe for concurrent use, as each
+// is only used for a single import.
+func NewShared(newImporter func() jsonnet.Importer) *Shared {
+	return &Shared{newImporter: newImporter}
+}
@@ -0,0 +1,72 @@
+package importer
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingImporter counts the imports made by every importer sharing its counter.
+type countingImporter struct {
+	importer jsonnet.Importer
+	count    *atomic.Int64
+}
+
+func (c *countingImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	c.count.Add(1)
+	return c.importer.Import(importedFrom, importedPath)
+}
+
+func TestShared(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int64
+
+	shared := NewShared(func() jsonnet.Importer {
+		return &countingImporter{
+			importer: &jsonnet.MemoryImporter{Data: map[string]jsonnet.Contents{
+				"lib.libsonnet": jsonnet.MakeContents("{}"),
+			}},
+			count: &count,
+		}
+	})
+
+	var wg sync.WaitGroup
+
+	results := make([]jsonnet.Contents, 8)
+
+	for i := range results {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			contents, foundAt, err := shared.Import("a/main.jsonnet", "lib.libsonnet")
+			assert.NoError(t, err)
+			assert.Equal(t, "lib.libsonnet", foundAt)
+
+			results[i] = contents
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, 1, count.Load(), "file should only be imported once")
+
+	// Imports of the same location from another directory return the same instance of the contents
+	contents, _, err := shared.Import("b/main.jsonnet", "lib.libsonnet")
+	require.NoError(t, err)
+
+	for _, result := range results {
+		assert.True(t, contents == result, "contents should be the same instance")
+	}
+
+	assert.EqualValues(t, 2, count.Load())
+
+	_, _, err = shared.Import("a/main.jsonnet", "missing.libsonnet")
+	require.Error(t, err)
+}
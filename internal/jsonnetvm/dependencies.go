@@ -0,0 +1,44 @@
+package jsonnetvm
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	jsonnet "github.com/google/go-jsonnet"
+)
+
+var errFindDependencies = errors.New("unable to find dependencies")
+
+// FindDependencies returns the absolute paths of fileName and every file it transitively imports,
+// in sorted order. go-jsonnet may panic while analysing invalid source, in which case an error
+// is returned.
+func FindDependencies(vm *jsonnet.VM, fileName string) (deps []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			deps = nil
+			err = fmt.Errorf("%s: jsonnet panicked with %v: %w", fileName, r, errFindDependencies)
+		}
+	}()
+
+	found, err := vm.FindDependencies("", []string{fileName})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", err, errFindDependencies)
+	}
+
+	deps = make([]string, 0, len(found)+1)
+
+	for _, dep := range append(found, fileName) {
+		abs, err := filepath.Abs(dep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", dep, err)
+		}
+
+		deps = append(deps, abs)
+	}
+
+	slices.Sort(deps)
+
+	return slices.Compact(deps), nil
+}
@@ -0,0 +1,313 @@
+// Package jsonnetvm builds Jsonnet VMs configured consistently across every command.
+package jsonnetvm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/bundler"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/importer"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/pkg/natives"
+)
+
+// Options configures the VMs created by a Builder. They are usually populated from
+// command line flags using AddFlags.
+type Options struct {
+	JPaths      []string
+	ExtStr      map[string]string
+	ExtCode     map[string]string
+	ExtStrFile  map[string]string
+	ExtCodeFile map[string]string
+	TLAStr      map[string]string
+	TLACode     map[string]string
+	TLAStrFile  map[string]string
+	TLACodeFile map[string]string
+	MaxStack    int
+	// JsonnetBundler enables detection of jsonnet-bundler projects containing the entrypoints.
+	JsonnetBundler bool
+}
+
+// AddFlags registers flags for every VM option on flags.
+func (o *Options) AddFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVarP(
+		&o.JPaths, "jpath", "J", nil,
+		"Specify an additional library search dir",
+	)
+	flags.StringToStringVarP(
+		&o.ExtStr, "ext-str", "V", map[string]string{},
+		"Provide an external value as a string to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.ExtCode, "ext-code", "C", map[string]string{},
+		"Provide an external value as Jsonnet code to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.ExtStrFile, "ext-str-file", "", map[string]string{},
+		"Provide an external value as a string, read from a file, to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.ExtCodeFile, "ext-code-file", "", map[string]string{},
+		"Provide an external value as Jsonnet code, read from a file, to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.TLAStr, "tla-str", "A", map[string]string{},
+		"Provide a top-level argument as a string to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.TLACode, "tla-code", "", map[string]string{},
+		"Provide a top-level argument as Jsonnet code to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.TLAStrFile, "tla-str-file", "", map[string]string{},
+		"Provide a top-level argument as a string, read from a file, to jsonnet",
+	)
+	flags.StringToStringVarP(
+		&o.TLACodeFile, "tla-code-file", "", map[string]string{},
+		"Provide a top-level argument as Jsonnet code, read from a file, to jsonnet",
+	)
+	flags.IntVarP(
+		&o.MaxStack, "max-stack", "s", 0,
+		"Number of allowed stack frames, or 0 for the Jsonnet default",
+	)
+	flags.BoolVarP(
+		&o.JsonnetBundler, "jsonnet-bundler", "", true,
+		"Add the vendor directory and legacy import aliases of jsonnet-bundler projects containing the entrypoints to the library search path",
+	)
+
+	// Marking paths allows them to be resolved relative to the project configuration file
+	_ = cobra.MarkFlagDirname(flags, "jpath")
+	for _, name := range []string{"ext-str-file", "ext-code-file", "tla-str-file", "tla-code-file"} {
+		_ = cobra.MarkFlagFilename(flags, name)
+	}
+}
+
+// Builder creates identically configured VMs. All VMs created by a Builder share a
+// single importer, caching imported files, so a Builder may be used to create VMs for
+// concurrent evaluations.
+type Builder struct {
+	importer jsonnet.Importer
+	jpaths   []string
+	extStr   map[string]string
+	extCode  map[string]string
+	tlaStr   map[string]string
+	tlaCode  map[string]string
+	maxStack int
+}
+
+// NewBuilder returns a Builder for the options, reading any file-based values. When jsonnet-bundler
+// detection is enabled, the vendor directory of any jsonnet-bundler project containing an entrypoint
+// is added to the library search path, with a lower precedence than the jpaths in the options, and
+// warnings about packages missing from the vendor directory are written to warnings.
+func (o *Options) NewBuilder(entrypoints []string, warnings io.Writer) (*Builder, error) {
+	jpaths := o.JPaths
+
+	var aliases map[string]string
+
+	if o.JsonnetBundler {
+		vendorDirs, bundlerAliases, err := findJsonnetBundlerProjects(entrypoints, warnings)
+		if err != nil {
+			return nil, err
+		}
+
+		// The right-most jpath takes precedence, so that explicit jpaths override vendor directories
+		jpaths = append(vendorDirs, jpaths...)
+		aliases = bundlerAliases
+	}
+
+	newImporter := func() jsonnet.Importer {
+		var fileImporter jsonnet.Importer = &jsonnet.FileImporter{
+			JPaths: jpaths,
+		}
+
+		if len(aliases) > 0 {
+			fileImporter = importer.NewAlias(fileImporter, aliases)
+		}
+
+		return fileImporter
+	}
+
+	b := &Builder{
+		importer: importer.NewShared(newImporter),
+		jpaths:   jpaths,
+		extStr:   map[string]string{},
+		extCode:  map[string]string{},
+		tlaStr:   map[string]string{},
+		tlaCode:  map[string]string{},
+		maxStack: o.MaxStack,
+	}
+
+	err := mergeValues(b.extStr, b.extCode, o.ExtStr, o.ExtCode, o.ExtStrFile, o.ExtCodeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read external variable: %w", err)
+	}
+
+	err = mergeValues(b.tlaStr, b.tlaCode, o.TLAStr, o.TLACode, o.TLAStrFile, o.TLACodeFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read top-level argument: %w", err)
+	}
+
+	return b, nil
+}
+
+// findJsonnetBundlerProjects returns the vendor directories and legacy import aliases of the jsonnet-bundler
+// projects containing entrypoints, warning about any packages missing from the vendor directories.
+func findJsonnetBundlerProjects(entrypoints []string, warnings io.Writer) ([]string, map[string]string, error) {
+	var vendorDirs []string
+
+	aliases := map[string]string{}
+	seen := map[string]bool{}
+
+	for _, entrypoint := range entrypoints {
+		project, err := bundler.Find(filepath.Dir(entrypoint))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load jsonnet-bundler project: %w", err)
+		}
+
+		if project == nil || seen[project.Dir] {
+			continue
+		}
+
+		seen[project.Dir] = true
+		vendorDirs = append(vendorDirs, project.VendorDir())
+
+		for k, v := range project.Aliases() {
+			if _, ok := aliases[k]; !ok {
+				aliases[k] = v
+			}
+		}
+
+		missing := project.Missing()
+		if len(missing) > 0 {
+			_, _ = fmt.Fprintf(warnings, "warning: jsonnet-bundler packages are missing from %s, run `jb install`: %s\n",
+				project.VendorDir(), strings.Join(missing, ", "))
+		}
+	}
+
+	return vendorDirs, aliases, nil
+}
+
+// mergeValues populates strValues and codeValues from the values given directly and in files.
+func mergeValues(strValues, codeValues, str, code, strFiles, codeFiles map[string]string) error {
+	for k, v := range str {
+		strValues[k] = v
+	}
+
+	for k, v := range code {
+		codeValues[k] = v
+	}
+
+	for k, fileName := range strFiles {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+
+		strValues[k] = string(content)
+	}
+
+	for k, fileName := range codeFiles {
+		// Importing the file, rather than evaluating its content, allows it to use
+		// imports relative to its own location. The path is made absolute, as the
+		// code may be evaluated from a snippet in another directory.
+		absFileName, err := filepath.Abs(fileName)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+
+		codeValues[k] = ImportCode(absFileName)
+	}
+
+	return nil
+}
+
+// Importer returns the importer shared by all VMs created by the Builder.
+func (b *Builder) Importer() jsonnet.Importer {
+	return b.importer
+}
+
+// JPaths returns the library search paths used by the importer, in search order. Imports are
+// first resolved relative to the importing file, then in each of the library search paths.
+func (b *Builder) JPaths() []string {
+	searchOrder := slices.Clone(b.jpaths)
+	slices.Reverse(searchOrder)
+
+	return searchOrder
+}
+
+// TopLevelCallArgs returns every top-level argument as the named arguments of a Jsonnet function
+// call, such as `a=("x"), b=(1)`, sorted by name. This allows the arguments to be passed to
+// functions other than the top-level function, such as test files evaluated within a snippet.
+func (b *Builder) TopLevelCallArgs() string {
+	args := make(map[string]string, len(b.tlaStr)+len(b.tlaCode))
+
+	for k, v := range b.tlaStr {
+		// Marshalling a string cannot fail, and JSON strings are valid Jsonnet
+		code, _ := json.Marshal(v)
+		args[k] = string(code)
+	}
+
+	for k, v := range b.tlaCode {
+		args[k] = v
+	}
+
+	names := make([]string, 0, len(args))
+	for k := range args {
+		names = append(names, k)
+	}
+
+	slices.Sort(names)
+
+	callArgs := make([]string, len(names))
+	for i, k := range names {
+		callArgs[i] = fmt.Sprintf("%s=(%s)", k, args[k])
+	}
+
+	return strings.Join(callArgs, ", ")
+}
+
+// MakeVM returns a new VM with the builder's importer, external variables, top-level arguments
+// and the natives from the natives package.
+func (b *Builder) MakeVM() *jsonnet.VM {
+	vm := jsonnet.MakeVM()
+	natives.Register(vm)
+
+	for k, v := range b.extStr {
+		vm.ExtVar(k, v)
+	}
+
+	for k, v := range b.extCode {
+		vm.ExtCode(k, v)
+	}
+
+	for k, v := range b.tlaStr {
+		vm.TLAVar(k, v)
+	}
+
+	for k, v := range b.tlaCode {
+		vm.TLACode(k, v)
+	}
+
+	if b.maxStack > 0 {
+		vm.MaxStack = b.maxStack
+	}
+
+	vm.ErrorFormatter.SetColorFormatter(color.New(color.FgRed).Fprintf)
+	vm.Importer(b.importer)
+
+	return vm
+}
+
+// ImportCode returns a Jsonnet expression importing fileName, escaping any quotes.
+func ImportCode(fileName string) string {
+	return fmt.Sprintf("import @'%s'", strings.ReplaceAll(fileName, "'", "''"))
+}
@@ -0,0 +1,150 @@
+package jsonnetvm
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilderMakeVM(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "str.txt"), []byte("from file"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "code.libsonnet"), []byte("import 'lib.libsonnet'"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib.libsonnet"), []byte("{ lib: true }"), 0o600))
+
+	tests := []struct {
+		name    string
+		options Options
+		snippet string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "ext_str",
+			options: Options{ExtStr: map[string]string{"a": "hello"}},
+			snippet: "std.extVar('a')",
+			want:    `"hello"`,
+		},
+		{
+			name:    "ext_code",
+			options: Options{ExtCode: map[string]string{"a": "1 + 1"}},
+			snippet: "std.extVar('a')",
+			want:    "2",
+		},
+		{
+			name:    "ext_str_file",
+			options: Options{ExtStrFile: map[string]string{"a": filepath.Join(dir, "str.txt")}},
+			snippet: "std.extVar('a')",
+			want:    `"from file"`,
+		},
+		{
+			name:    "ext_code_file",
+			options: Options{ExtCodeFile: map[string]string{"a": filepath.Join(dir, "code.libsonnet")}},
+			snippet: "std.extVar('a').lib",
+			want:    "true",
+		},
+		{
+			name:    "ext_str_file_missing",
+			options: Options{ExtStrFile: map[string]string{"a": filepath.Join(dir, "missing.txt")}},
+			wantErr: true,
+		},
+		{
+			name:    "tla",
+			options: Options{TLAStr: map[string]string{"a": "x"}, TLACode: map[string]string{"b": "2"}},
+			snippet: "function(a, b) a + b",
+			want:    `"x2"`,
+		},
+		{
+			name: "tla_files",
+			options: Options{
+				TLAStrFile:  map[string]string{"a": filepath.Join(dir, "str.txt")},
+				TLACodeFile: map[string]string{"b": filepath.Join(dir, "code.libsonnet")},
+			},
+			snippet: "function(a, b) [a, b.lib]",
+			want:    `["from file", true]`,
+		},
+		{
+			name:    "jpath",
+			options: Options{JPaths: []string{dir}},
+			snippet: "(import 'lib.libsonnet').lib",
+			want:    "true",
+		},
+		{
+			name:    "natives",
+			snippet: "std.native('regexMatch')('^a', 'abc')",
+			want:    "true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			builder, err := tt.options.NewBuilder(nil, io.Discard)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got, err := builder.MakeVM().EvaluateAnonymousSnippet("test.jsonnet", tt.snippet)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestBuilderTopLevelCallArgs(t *testing.T) {
+	t.Parallel()
+
+	options := Options{
+		TLAStr:  map[string]string{"a": "it's \"quoted\""},
+		TLACode: map[string]string{"b": "{ c: 1 }"},
+	}
+
+	builder, err := options.NewBuilder(nil, io.Discard)
+	require.NoError(t, err)
+
+	assert.Equal(t, `a=("it's \"quoted\""), b=({ c: 1 })`, builder.TopLevelCallArgs())
+}
+
+func TestBuilderJsonnetBundler(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	jsonnetFile := `{"dependencies": [{"source": {"git": {"remote": "https://github.com/example/lib.git", "subdir": "pkg"}}}]}`
+	pkgDir := filepath.Join(dir, "vendor", "github.com", "example", "lib", "pkg")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jsonnetfile.json"), []byte(jsonnetFile), 0o600))
+	require.NoError(t, os.MkdirAll(pkgDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "main.libsonnet"), []byte("{ vendored: true }"), 0o600))
+
+	entrypoint := filepath.Join(dir, "main.jsonnet")
+	require.NoError(t, os.WriteFile(entrypoint, []byte(
+		"(import 'github.com/example/lib/pkg/main.libsonnet') + (import 'pkg/main.libsonnet')",
+	), 0o600))
+
+	options := Options{JsonnetBundler: true}
+
+	builder, err := options.NewBuilder([]string{entrypoint}, io.Discard)
+	require.NoError(t, err)
+
+	got, err := builder.MakeVM().EvaluateFile(entrypoint)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"vendored": true}`, got)
+
+	options.JsonnetBundler = false
+
+	builder, err = options.NewBuilder([]string{entrypoint}, io.Discard)
+	require.NoError(t, err)
+
+	_, err = builder.MakeVM().EvaluateFile(entrypoint)
+	require.Error(t, err)
+}
@@ -0,0 +1,174 @@
+// Package lint finds problems in Jsonnet files using the go-jsonnet linter, along with
+// additional checks, and reports them in formats suitable for humans and tools.
+package lint
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"github.com/google/go-jsonnet/linter"
+)
+
+var errLintFailed = errors.New("lint failed")
+
+const (
+	// RuleUnusedVariable reports local variables and parameters which are never used.
+	RuleUnusedVariable = "unused-variable"
+	// RuleShadowedVariable reports variables which hide a variable of the same name from an enclosing scope.
+	RuleShadowedVariable = "shadowed-variable"
+	// RuleEndlessLoop reports local definitions which refer to themselves, such as `local x = x + 1`.
+	RuleEndlessLoop = "endless-loop"
+	// RuleType reports values which are used inconsistently with their type, such as calling a non-function.
+	RuleType = "type"
+	// RuleError reports syntax errors, undeclared variables and imports which cannot be resolved.
+	RuleError = "error"
+)
+
+// Rules describes each rule, keyed on rule name.
+var Rules = map[string]string{
+	RuleUnusedVariable:   "Variable is never used",
+	RuleShadowedVariable: "Variable shadows a variable from an enclosing scope",
+	RuleEndlessLoop:      "Local definition refers to itself",
+	RuleType:             "Value is used inconsistently with its type",
+	RuleError:            "Syntax error, undeclared variable or unresolved import",
+}
+
+// typeMessagePrefixes are the prefixes of the messages of the go-jsonnet linter's type checks.
+var typeMessagePrefixes = []string{
+	"Called value", "Too few arguments", "Too many arguments", "Indexed", "Index is", "Operand is",
+	"Argument ", "function has no parameter", "Missing argument",
+}
+
+// Finding is a problem found in a file.
+type Finding struct {
+	Rule      string `json:"rule"`
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	Message   string `json:"message"`
+}
+
+// Lint returns the findings in files, sorted by position, excluding any which are suppressed by comments.
+// vm is used to resolve imports, and must not be used concurrently.
+func Lint(vm *jsonnet.VM, files []string) ([]*Finding, error) {
+	snippets := make([]linter.Snippet, 0, len(files))
+	sources := map[string]string{}
+
+	for _, fileName := range files {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", err, errLintFailed)
+		}
+
+		sources[fileName] = string(content)
+		snippets = append(snippets, linter.Snippet{FileName: fileName, Code: string(content)})
+	}
+
+	// The linter only reports findings through the VM's error formatter
+	collector := &findingCollector{}
+
+	errorFormatter := vm.ErrorFormatter
+	vm.ErrorFormatter = collector
+
+	defer func() {
+		vm.ErrorFormatter = errorFormatter
+	}()
+
+	linter.LintSnippet(vm, io.Discard, snippets)
+
+	findings := collector.findings
+
+	for _, snippet := range snippets {
+		node, err := jsonnet.SnippetToAST(snippet.FileName, snippet.Code)
+		if err != nil {
+			// Already reported by the linter
+			continue
+		}
+
+		findings = append(findings, findShadowedVariables(node)...)
+	}
+
+	findings, err := suppress(findings, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(findings, compareFindings)
+
+	return slices.CompactFunc(findings, func(a, b *Finding) bool {
+		return *a == *b
+	}), nil
+}
+
+func compareFindings(a, b *Finding) int {
+	return cmp.Or(
+		cmp.Compare(a.File, b.File),
+		cmp.Compare(a.Line, b.Line),
+		cmp.Compare(a.Column, b.Column),
+		cmp.Compare(a.Rule, b.Rule),
+		cmp.Compare(a.Message, b.Message),
+	)
+}
+
+// findingCollector is a jsonnet.ErrorFormatter which records the errors it formats as findings.
+type findingCollector struct {
+	findings []*Finding
+}
+
+func (c *findingCollector) Format(err error) string {
+	var located interface{ Loc() ast.LocationRange }
+	if !errors.As(err, &located) {
+		c.findings = append(c.findings, &Finding{Rule: RuleError, Message: err.Error()})
+		return ""
+	}
+
+	loc := located.Loc()
+	message := strings.TrimPrefix(err.Error(), loc.String()+" ")
+
+	c.findings = append(c.findings, newFinding(classify(message), loc, message))
+
+	return ""
+}
+
+func (c *findingCollector) SetMaxStackTraceSize(size int) {}
+
+func (c *findingCollector) SetColorFormatter(color jsonnet.ColorFormatter) {}
+
+func newFinding(rule string, loc ast.LocationRange, message string) *Finding {
+	return &Finding{
+		Rule:      rule,
+		File:      loc.FileName,
+		Line:      loc.Begin.Line,
+		Column:    loc.Begin.Column,
+		EndLine:   loc.End.Line,
+		EndColumn: loc.End.Column,
+		Message:   message,
+	}
+}
+
+// classify returns the rule for a message from the go-jsonnet linter.
+func classify(message string) string {
+	switch {
+	case strings.HasPrefix(message, "Unused variable"):
+		return RuleUnusedVariable
+	case strings.HasPrefix(message, "Endless loop"):
+		return RuleEndlessLoop
+	}
+
+	for _, prefix := range typeMessagePrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return RuleType
+		}
+	}
+
+	return RuleError
+}
@@ -0,0 +1,160 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name:   "clean",
+			source: "local a = 1; { a: a }",
+		},
+		{
+			name:   "unused_variable",
+			source: "local a = 1;\n{}",
+			want:   []string{"1:7 unused-variable Unused variable: a"},
+		},
+		{
+			name:   "shadowed_local",
+			source: "local a = 1;\nlocal f() = local a = 2; a;\n{ a: a, f: f() }",
+			want:   []string{"2:19 shadowed-variable Variable a shadows a variable of the same name"},
+		},
+		{
+			name:   "shadowed_parameter",
+			source: "local a = 1;\nlocal f(a) = a;\n{ a: a, f: f(2) }",
+			want:   []string{"2:9 shadowed-variable Variable a shadows a variable of the same name"},
+		},
+		{
+			name:   "shadowed_object_local",
+			source: "{\n  local a = 1,\n  x: { local a = 2, y: a },\n  z: a,\n}",
+			want:   []string{"3:14 shadowed-variable Variable a shadows a variable of the same name"},
+		},
+		{
+			name:   "sibling_scopes",
+			source: "{ x: local a = 1; a, y: local a = 2; a, z: [a for a in [1]], w: function(a) a }",
+		},
+		{
+			name:   "type",
+			source: "local o = { a: 1 };\no.b",
+			want:   []string{"2:1 type Indexed object has no field \"b\""},
+		},
+		{
+			name:   "unresolved_import",
+			source: "import 'missing.libsonnet'",
+			want:   []string{"1:1 error couldn't open import \"missing.libsonnet\": no match locally or in the Jsonnet library paths"},
+		},
+		{
+			name:   "syntax_error",
+			source: "{",
+			want:   []string{"1:2 error Unexpected: end of file while parsing field definition"},
+		},
+		{
+			name:   "suppressed_same_line",
+			source: "local a = 1;  // jsonnet-tool:lint-ignore\n{}",
+		},
+		{
+			name:   "suppressed_preceding_line",
+			source: "# jsonnet-tool:lint-ignore unused-variable\nlocal a = 1;\n{}",
+		},
+		{
+			name:   "suppressed_other_rule",
+			source: "// jsonnet-tool:lint-ignore shadowed-variable\nlocal a = 1;\n{}",
+			want:   []string{"2:7 unused-variable Unused variable: a"},
+		},
+		{
+			name:   "suppressed_file",
+			source: "local a = 1;\nlocal b = 2;\n{}\n/* jsonnet-tool:lint-ignore-file unused-variable */",
+		},
+		{
+			name:   "suppression_does_not_reach",
+			source: "// jsonnet-tool:lint-ignore\n\nlocal a = 1;\n{}",
+			want:   []string{"3:7 unused-variable Unused variable: a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fileName := filepath.Join(t.TempDir(), "file.jsonnet")
+			require.NoError(t, os.WriteFile(fileName, []byte(tt.source), 0o600))
+
+			findings, err := Lint(jsonnet.MakeVM(), []string{fileName})
+			require.NoError(t, err)
+
+			var got []string
+
+			for _, f := range findings {
+				assert.Equal(t, fileName, f.File)
+				got = append(got, fmt.Sprintf("%d:%d %s %s", f.Line, f.Column, f.Rule, f.Message))
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	findings := []*Finding{
+		{Rule: RuleUnusedVariable, File: "lib/a.libsonnet", Line: 1, Column: 7, EndLine: 1, EndColumn: 12, Message: "Unused variable: a"},
+		{Rule: RuleError, File: "b.jsonnet", Line: 2, Column: 1, EndLine: 2, EndColumn: 1, Message: "Unexpected: end of file"},
+	}
+
+	var text bytes.Buffer
+	require.NoError(t, Write(&text, findings, OutputFormatText, "1.0.0"))
+	assert.Equal(t, "lib/a.libsonnet:1:7: Unused variable: a (unused-variable)\nb.jsonnet:2:1: Unexpected: end of file (error)\n", text.String())
+
+	var empty bytes.Buffer
+	require.NoError(t, Write(&empty, nil, OutputFormatJSON, "1.0.0"))
+	assert.JSONEq(t, `{"findings": []}`, empty.String())
+
+	var sarif bytes.Buffer
+	require.NoError(t, Write(&sarif, findings, OutputFormatSARIF, "1.0.0"))
+
+	var log sarifReport
+	require.NoError(t, json.Unmarshal(sarif.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	assert.Equal(t, "1.0.0", log.Runs[0].Tool.Driver.Version)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(Rules))
+	assert.Equal(t, []sarifResult{
+		{
+			RuleID:  RuleUnusedVariable,
+			Level:   "warning",
+			Message: sarifMessage{Text: "Unused variable: a"},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "lib/a.libsonnet"},
+				Region:           &sarifRegion{StartLine: 1, StartColumn: 7, EndLine: 1, EndColumn: 12},
+			}}},
+		},
+		{
+			RuleID:  RuleError,
+			Level:   "error",
+			Message: sarifMessage{Text: "Unexpected: end of file"},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "b.jsonnet"},
+				Region:           &sarifRegion{StartLine: 2, StartColumn: 1, EndLine: 2, EndColumn: 1},
+			}}},
+		},
+	}, log.Runs[0].Results)
+
+	require.Error(t, Write(&text, findings, "xml", "1.0.0"))
+}
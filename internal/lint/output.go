@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	// OutputFormatText lists each finding on a line.
+	OutputFormatText = "text"
+
+	// OutputFormatJSON emits a JSON document listing each finding.
+	OutputFormatJSON = "json"
+
+	// OutputFormatSARIF emits a SARIF 2.1.0 log, for code scanning and code quality tools.
+	OutputFormatSARIF = "sarif"
+)
+
+// OutputFormats are the supported formats for Write.
+var OutputFormats = []string{OutputFormatText, OutputFormatJSON, OutputFormatSARIF}
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "jsonnet-tool"
+	toolURI      = "https://gitlab.com/gitlab-com/gl-infra/jsonnet-tool"
+)
+
+type report struct {
+	Findings []*Finding `json:"findings"`
+}
+
+// Write writes the findings to w in the given output format. version is the version of
+// jsonnet-tool, which is recorded in SARIF output.
+func Write(w io.Writer, findings []*Finding, outputFormat string, version string) error {
+	var err error
+
+	switch outputFormat {
+	case OutputFormatText:
+		for _, f := range findings {
+			_, err = fmt.Fprintf(w, "%s:%d:%d: %s (%s)\n", f.File, f.Line, f.Column, f.Message, f.Rule)
+			if err != nil {
+				break
+			}
+		}
+	case OutputFormatJSON:
+		err = writeJSON(w, report{Findings: append([]*Finding{}, findings...)})
+	case OutputFormatSARIF:
+		err = writeJSON(w, sarifLog(findings, version))
+	default:
+		return fmt.Errorf("unknown output format %q: %w", outputFormat, errLintFailed)
+	}
+
+	if err != nil {
+		return fmt.Errorf("write failed: %w: %w", err, errLintFailed)
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+func sarifLog(findings []*Finding, version string) sarifReport {
+	ruleIDs := make([]string, 0, len(Rules))
+	for id := range Rules {
+		ruleIDs = append(ruleIDs, id)
+	}
+
+	slices.Sort(ruleIDs)
+
+	rules := make([]sarifRule, len(ruleIDs))
+	for i, id := range ruleIDs {
+		rules[i] = sarifRule{ID: id, ShortDescription: sarifMessage{Text: Rules[id]}}
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+
+	for _, f := range findings {
+		level := "warning"
+		if f.Rule == RuleError {
+			level = "error"
+		}
+
+		result := sarifResult{RuleID: f.Rule, Level: level, Message: sarifMessage{Text: f.Message}}
+
+		if f.File != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(f.File)},
+			}}
+
+			if f.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   f.Line,
+					StartColumn: f.Column,
+					EndLine:     f.EndLine,
+					EndColumn:   f.EndColumn,
+				}
+			}
+
+			result.Locations = []sarifLocation{location}
+		}
+
+		results = append(results, result)
+	}
+
+	return sarifReport{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           toolName,
+				Version:        version,
+				InformationURI: toolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
@@ -0,0 +1,97 @@
+package lint
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/google/go-jsonnet/ast"
+	"github.com/google/go-jsonnet/toolutils"
+)
+
+// findShadowedVariables returns a finding for each local variable or function parameter in node
+// which has the same name as a variable in an enclosing scope. node must be desugared, as returned
+// by jsonnet.SnippetToAST. Variables introduced by desugaring, which start with `$`, are ignored.
+func findShadowedVariables(node ast.Node) []*Finding {
+	var findings []*Finding
+
+	walkScopes(node, map[ast.Identifier]bool{}, &findings)
+
+	return findings
+}
+
+// walkScopes walks node, where scope holds the variables which are visible.
+func walkScopes(node ast.Node, scope map[ast.Identifier]bool, findings *[]*Finding) {
+	if node == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *ast.Local:
+		inner := bindScope(n.Binds, scope, findings)
+
+		for _, bind := range n.Binds {
+			walkScopes(bind.Body, inner, findings)
+		}
+
+		walkScopes(n.Body, inner, findings)
+	case *ast.DesugaredObject:
+		inner := bindScope(n.Locals, scope, findings)
+
+		for _, bind := range n.Locals {
+			walkScopes(bind.Body, inner, findings)
+		}
+
+		for _, field := range n.Fields {
+			// Field names are evaluated outside of the object
+			walkScopes(field.Name, scope, findings)
+			walkScopes(field.Body, inner, findings)
+		}
+
+		for _, assert := range n.Asserts {
+			walkScopes(assert, inner, findings)
+		}
+	case *ast.Function:
+		inner := maps.Clone(scope)
+
+		for _, param := range n.Parameters {
+			checkShadowed(param.Name, param.LocRange, n.Loc(), scope, findings)
+			inner[param.Name] = true
+		}
+
+		for _, param := range n.Parameters {
+			walkScopes(param.DefaultArg, inner, findings)
+		}
+
+		walkScopes(n.Body, inner, findings)
+	default:
+		for _, child := range toolutils.Children(node) {
+			walkScopes(child, scope, findings)
+		}
+	}
+}
+
+// bindScope returns a scope with the variables bound by binds added to scope, which are
+// visible to each other, reporting any that shadow a variable in scope.
+func bindScope(binds ast.LocalBinds, scope map[ast.Identifier]bool, findings *[]*Finding) map[ast.Identifier]bool {
+	inner := maps.Clone(scope)
+
+	for _, bind := range binds {
+		checkShadowed(bind.Variable, bind.LocRange, bind.Body.Loc(), scope, findings)
+		inner[bind.Variable] = true
+	}
+
+	return inner
+}
+
+func checkShadowed(name ast.Identifier, loc ast.LocationRange, fallback *ast.LocationRange, scope map[ast.Identifier]bool, findings *[]*Finding) {
+	if strings.HasPrefix(string(name), "$") || !scope[name] {
+		return
+	}
+
+	if !loc.IsSet() && fallback != nil {
+		loc = *fallback
+	}
+
+	*findings = append(*findings, newFinding(RuleShadowedVariable, loc, fmt.Sprintf("Variable %s shadows a variable of the same name", name)))
+}
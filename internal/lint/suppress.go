@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// suppressionPattern matches suppression comments, such as `// jsonnet-tool:lint-ignore unused-variable`.
+// A comment without rules suppresses every rule.
+var suppressionPattern = regexp.MustCompile(`(?://|#|/\*)\s*jsonnet-tool:lint-ignore(-file)?((?:[ \t]+[a-z][a-z-]*)*)`)
+
+// suppression is a suppression comment. A nil rules slice suppresses every rule.
+type suppression struct {
+	rules []string
+}
+
+func (s suppression) suppresses(rule string) bool {
+	return s.rules == nil || slices.Contains(s.rules, rule)
+}
+
+// suppressions are the suppression comments within a file.
+type suppressions struct {
+	// lines holds the suppressions on each line, keyed on line number
+	lines map[int][]suppression
+	file  []suppression
+}
+
+// parseSuppressions finds the suppression comments in source.
+func parseSuppressions(source string) suppressions {
+	s := suppressions{lines: map[int][]suppression{}}
+
+	for i, line := range strings.Split(source, "\n") {
+		for _, match := range suppressionPattern.FindAllStringSubmatch(line, -1) {
+			sup := suppression{rules: strings.Fields(match[2])}
+			if len(sup.rules) == 0 {
+				sup.rules = nil
+			}
+
+			if match[1] != "" {
+				s.file = append(s.file, sup)
+			} else {
+				s.lines[i+1] = append(s.lines[i+1], sup)
+			}
+		}
+	}
+
+	return s
+}
+
+// suppressed returns true if the finding is suppressed by a comment on the same line,
+// the line before, or anywhere in the file, for comments which apply to the whole file.
+func (s suppressions) suppressed(f *Finding) bool {
+	candidates := slices.Concat(s.file, s.lines[f.Line], s.lines[f.Line-1])
+
+	return slices.ContainsFunc(candidates, func(sup suppression) bool {
+		return sup.suppresses(f.Rule)
+	})
+}
+
+// suppress returns the findings which are not suppressed by comments. sources holds the content of
+// files which have already been read, keyed on file name, and other files are read as required.
+func suppress(findings []*Finding, sources map[string]string) ([]*Finding, error) {
+	parsed := map[string]suppressions{}
+
+	var unsuppressed []*Finding
+
+	for _, f := range findings {
+		s, ok := parsed[f.File]
+		if !ok {
+			source, ok := sources[f.File]
+			if !ok && f.File != "" {
+				content, err := os.ReadFile(f.File)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %w", err, errLintFailed)
+				}
+
+				source = string(content)
+			}
+
+			s = parseSuppressions(source)
+			parsed[f.File] = s
+		}
+
+		if !s.suppressed(f) {
+			unsuppressed = append(unsuppressed, f)
+		}
+	}
+
+	return unsuppressed, nil
+}
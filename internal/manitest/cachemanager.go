@@ -24,6 +24,7 @@ type CacheResults map[string]*CacheResult
 
 type CacheManager struct {
 	vm           *jsonnet.VM
+	topLevelArgs string
 	cacheResults CacheResults
 	hashCache    map[string]string
 }
@@ -53,7 +54,7 @@ func (c *CacheManager) LoadCachedResults() error {
 }
 
 const evaluateTestFixturesSnippet = `
-	local ts = import '%s';
+	%s
 
 	std.foldl(
 		function(memo, k)
@@ -126,14 +127,20 @@ func (c *CacheManager) getHash(fileName string) (string, error) {
 }
 
 // calculateHashSum generates a unique hash based on the content of all files
-// used in the test, including jsonnet, imports, test fixtures.
+// used in the test, including jsonnet, imports, test fixtures, and the top-level
+// arguments passed to the test.
 func (c *CacheManager) calculateHashSum(fileName string) (string, error) {
-	deps, err := c.listAllDependencies(fileName)
+	deps, err := c.ListAllDependencies(fileName)
 	if err != nil {
 		return "", fmt.Errorf("failed to list dependencies: %w", err)
 	}
 
 	h := sha256.New()
+
+	// Hash a canonical form of the top-level arguments, as a test file
+	// gives different results for different arguments.
+	_, _ = fmt.Fprint(h, importTests(fileName, c.topLevelArgs))
+
 	for _, fileName := range deps {
 		err = addFileForHashing(h, fileName)
 		if err != nil {
@@ -144,9 +151,9 @@ func (c *CacheManager) calculateHashSum(fileName string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// listAllDependencies function will inspect a test and return a stable set of all unique dependencies
-// for that test file.
-func (c *CacheManager) listAllDependencies(fileName string) ([]string, error) {
+// ListAllDependencies inspects a test file and returns a stable, sorted set of all unique dependencies
+// for that test file, including the test file itself, its imports and its fixtures.
+func (c *CacheManager) ListAllDependencies(fileName string) ([]string, error) {
 	results := map[string]struct{}{}
 
 	results[fileName] = struct{}{}
@@ -160,7 +167,7 @@ func (c *CacheManager) listAllDependencies(fileName string) ([]string, error) {
 		results[dep] = struct{}{}
 	}
 
-	testManifest, err := c.vm.EvaluateAnonymousSnippet(fileName, fmt.Sprintf(evaluateTestFixturesSnippet, fileName))
+	testManifest, err := c.vm.EvaluateAnonymousSnippet(fileName, fmt.Sprintf(evaluateTestFixturesSnippet, importTests(fileName, c.topLevelArgs)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute test: %w", err)
 	}
@@ -254,9 +261,10 @@ func addFileForHashing(h hash.Hash, fileName string) error {
 	return nil
 }
 
-func NewCacheManager(vm *jsonnet.VM) *CacheManager {
+func NewCacheManager(vm *jsonnet.VM, topLevelArgs string) *CacheManager {
 	return &CacheManager{
 		vm:           vm,
+		topLevelArgs: topLevelArgs,
 		cacheResults: CacheResults{},
 		hashCache:    map[string]string{},
 	}
@@ -17,12 +17,18 @@ var errSetupTestFailed = errors.New("unable to execute test")
 var errTestFailed = errors.New("test failed")
 
 type TestRunner struct {
-	vm      *jsonnet.VM
-	visitor TestVisitor
+	vm           *jsonnet.VM
+	visitor      TestVisitor
+	topLevelArgs string
 }
 
+const importTestsSnippet = `
+	local file = import '%s';
+	local ts = if std.isFunction(file) then file(%s) else file;
+`
+
 const runTestsSnippet = `
-	local ts = import '%s';
+	%s
 
 	local testStart = std.native('testStart');
 	local testCompleted = std.native('testCompleted');
@@ -137,8 +143,17 @@ func warnVisitor(err error) {
 	}
 }
 
+// importTests returns Jsonnet binding `ts` to the test cases in fileName. Test files may be
+// functions, in which case they are called with topLevelArgs, given as the named arguments of
+// a function call, allowing a test file to be run against several configurations.
+func importTests(fileName string, topLevelArgs string) string {
+	return fmt.Sprintf(importTestsSnippet, fileName, topLevelArgs)
+}
+
 func (c *TestRunner) obtainTestCases(fileName string) (TestCases, error) {
-	testManifest, err := c.vm.EvaluateAnonymousSnippet("testrunner.go", fmt.Sprintf(runTestsSnippet, fileName))
+	snippet := fmt.Sprintf(runTestsSnippet, importTests(fileName, c.topLevelArgs))
+
+	testManifest, err := c.vm.EvaluateAnonymousSnippet("testrunner.go", snippet)
 	if err != nil {
 		return nil, fmt.Errorf("jsonnet evaluation failed: %w", err)
 	}
@@ -211,6 +226,8 @@ func testCaseResultForError(err error) *TestCaseResult {
 	}
 }
 
-func NewTestRunner(vm *jsonnet.VM, visitor TestVisitor) *TestRunner {
-	return &TestRunner{vm, visitor}
+// NewTestRunner returns a TestRunner. Test files which are functions are called with
+// topLevelArgs, which are given as the named arguments of a function call.
+func NewTestRunner(vm *jsonnet.VM, visitor TestVisitor, topLevelArgs string) *TestRunner {
+	return &TestRunner{vm, visitor, topLevelArgs}
 }
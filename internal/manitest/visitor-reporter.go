@@ -1,7 +1,6 @@
 package manitest
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
@@ -12,11 +11,9 @@ import (
 
 	"github.com/kr/text"
 
-	"github.com/hexops/gotextdiff"
-	"github.com/hexops/gotextdiff/myers"
-	"github.com/hexops/gotextdiff/span"
-
 	"github.com/alessio/shellescape"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/diff"
 )
 
 type ReporterVisitor struct {
@@ -187,35 +184,7 @@ func (rv *ReporterVisitor) TestCaseInvalid(name string, testcase string, err err
 
 // generatePrettyDiff will generate a diff for display.
 func (rv *ReporterVisitor) generatePrettyDiff(result *TestCaseResult, expected, actual string) string {
-	edits := myers.ComputeEdits(span.URIFromPath(result.FixturePath), expected, actual)
-	diff := fmt.Sprint(gotextdiff.ToUnified(result.FixturePath, result.FixturePath, expected, edits))
-
-	out := ""
-
-	// Remove the first two lines of the unified diff
-	count := 0
-	scanner := bufio.NewScanner(strings.NewReader(diff))
-
-	for scanner.Scan() {
-		count = count + 1
-
-		if count > 3 {
-			out = out + "\n"
-		}
-
-		if count > 2 {
-			line := scanner.Text()
-			if strings.HasPrefix(line, "-") {
-				out = out + color.RedString(scanner.Text())
-			} else if strings.HasPrefix(line, "+") {
-				out = out + color.YellowString(scanner.Text())
-			} else {
-				out = out + scanner.Text()
-			}
-		}
-	}
-
-	return out
+	return diff.Pretty(result.FixturePath, expected, actual)
 }
 
 func (rv *ReporterVisitor) AllTestsCompleted() error {
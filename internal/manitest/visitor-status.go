@@ -0,0 +1,56 @@
+package manitest
+
+// TestFileStatus is the outcome of running a test file.
+type TestFileStatus int
+
+const (
+	TestFilePassed TestFileStatus = iota
+	TestFileFailed
+	TestFileInvalid
+)
+
+// StatusVisitor records the outcome of each test file.
+type StatusVisitor struct {
+	// Statuses holds the outcome of each completed test file, keyed on file name.
+	Statuses map[string]TestFileStatus
+
+	invalid bool
+
+	baseVisitor
+}
+
+var _ TestVisitor = &StatusVisitor{}
+
+func (sv *StatusVisitor) TestFileStarted(fileName string) error {
+	sv.invalid = false
+	return nil
+}
+
+func (sv *StatusVisitor) TestFileInvalid(name string, err error) error {
+	sv.invalid = true
+	return nil
+}
+
+func (sv *StatusVisitor) TestCaseInvalid(name string, testcase string, err error) error {
+	sv.invalid = true
+	return nil
+}
+
+func (sv *StatusVisitor) TestFileCompleted(fileName string, allSuccessful bool) error {
+	switch {
+	case sv.invalid:
+		sv.Statuses[fileName] = TestFileInvalid
+	case !allSuccessful:
+		sv.Statuses[fileName] = TestFileFailed
+	default:
+		sv.Statuses[fileName] = TestFilePassed
+	}
+
+	return nil
+}
+
+func NewStatusVisitor() *StatusVisitor {
+	return &StatusVisitor{
+		Statuses: map[string]TestFileStatus{},
+	}
+}
@@ -0,0 +1,108 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/kr/text"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/diff"
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/exitcode"
+)
+
+// CheckFiles compares rendered files against the output directory, without modifying it.
+// A diff is written to out for every file which is missing, has changed, or is present
+// in the output directory's manifest but would no longer be generated.
+// Returns an exitcode.Outdated error if the output directory is not up to date.
+func CheckFiles(files []*File, options Options, out io.Writer) error {
+	outdated := 0
+
+	sorted := slices.Clone(files)
+	slices.SortFunc(sorted, func(a, b *File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	for _, file := range sorted {
+		filePath := filepath.Clean(file.Path)
+
+		current, err := os.ReadFile(filePath)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("unable to read %s: %w: %w", filePath, err, errRenderFailure)
+			}
+
+			outdated = outdated + 1
+			writeCheckResult(out, color.HiRedString("missing"), filePath, "", string(file.Content))
+
+			continue
+		}
+
+		if string(current) != string(file.Content) {
+			outdated = outdated + 1
+			writeCheckResult(out, color.HiYellowString("changed"), filePath, string(current), string(file.Content))
+		}
+	}
+
+	extra, err := findExtraFiles(files, options)
+	if err != nil {
+		return err
+	}
+
+	for _, filePath := range extra {
+		current, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w: %w", filePath, err, errRenderFailure)
+		}
+
+		outdated = outdated + 1
+		writeCheckResult(out, color.HiMagentaString("extra"), filePath, string(current), "")
+	}
+
+	if outdated > 0 {
+		return fmt.Errorf("generated files out of date: %d: %w", outdated, exitcode.Outdated())
+	}
+
+	return nil
+}
+
+func writeCheckResult(out io.Writer, status string, filePath string, current string, rendered string) {
+	_, _ = fmt.Fprintf(out, "%s %s\n", status, filePath)
+	_, _ = fmt.Fprintf(out, "%s\n\n", text.Indent(diff.Pretty(filePath, current, rendered), "    "))
+}
+
+// findExtraFiles returns, in lexical order, the files previously generated by the same sources
+// which would no longer be generated, as recorded by the manifest of the output directory.
+// Without a manifest, there is no record of which files were generated, so no files are extra.
+func findExtraFiles(files []*File, options Options) ([]string, error) {
+	manifest, err := LoadManifest(options.MultiDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !manifest.Exists() {
+		return nil, nil
+	}
+
+	stale, err := manifest.Stale(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra []string
+
+	for _, filePath := range stale {
+		_, err := os.Stat(filePath)
+		if err == nil {
+			extra = append(extra, filePath)
+		}
+	}
+
+	return extra, nil
+}
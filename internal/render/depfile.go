@@ -0,0 +1,110 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// WriteDepfile writes a Makefile fragment to depfilePath, in the format written by `gcc -MD`. Each
+// entrypoint gets a rule listing the files it generates as targets, and the files it depends upon as
+// prerequisites, with the entrypoint first, so that it is available to make as `$<`. outputs and
+// dependencies are keyed on entrypoint, and entrypoints which generate no files are omitted. Paths are
+// made relative to the working directory where possible.
+func WriteDepfile(depfilePath string, outputs map[string][]string, dependencies map[string][]string) error {
+	entrypoints := make([]string, 0, len(outputs))
+	for entrypoint, targets := range outputs {
+		if len(targets) > 0 {
+			entrypoints = append(entrypoints, entrypoint)
+		}
+	}
+
+	slices.Sort(entrypoints)
+
+	var b strings.Builder
+
+	for _, entrypoint := range entrypoints {
+		targets := slices.Clone(outputs[entrypoint])
+		slices.Sort(targets)
+
+		for i, target := range targets {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+
+			b.WriteString(escapeMakePath(relativePath(target)))
+		}
+
+		b.WriteString(":")
+
+		entrypointPath := relativePath(entrypoint)
+		prerequisites := []string{entrypointPath}
+
+		for _, dependency := range dependencies[entrypoint] {
+			dependencyPath := relativePath(dependency)
+			if dependencyPath != entrypointPath {
+				prerequisites = append(prerequisites, dependencyPath)
+			}
+		}
+
+		for _, prerequisite := range prerequisites {
+			b.WriteString(" \\\n  ")
+			b.WriteString(escapeMakePath(prerequisite))
+		}
+
+		b.WriteString("\n")
+	}
+
+	err := os.WriteFile(depfilePath, []byte(b.String()), 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write depfile: %w: %w", err, errRenderFailure)
+	}
+
+	return nil
+}
+
+// relativePath returns filePath relative to the working directory, unless it is outside of the working directory.
+func relativePath(filePath string) string {
+	if !filepath.IsAbs(filePath) {
+		return filepath.Clean(filePath)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return filePath
+	}
+
+	rel, err := filepath.Rel(wd, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filePath
+	}
+
+	return rel
+}
+
+// escapeMakePath escapes the characters in filePath which are special to make, as gcc does.
+func escapeMakePath(filePath string) string {
+	var b strings.Builder
+
+	for i, r := range filePath {
+		switch r {
+		case ' ', '\t':
+			// Any backslashes preceding a space must also be escaped
+			for j := i - 1; j >= 0 && filePath[j] == '\\'; j-- {
+				b.WriteByte('\\')
+			}
+
+			b.WriteByte('\\')
+		case '$':
+			b.WriteByte('$')
+		case '#':
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
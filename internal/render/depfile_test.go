@@ -0,0 +1,31 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeMakePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+	}{
+		{name: "plain", filePath: "out/a.yaml", want: "out/a.yaml"},
+		{name: "space", filePath: "out/a b.yaml", want: `out/a\ b.yaml`},
+		{name: "backslash_before_space", filePath: `out/a\ b.yaml`, want: `out/a\\\ b.yaml`},
+		{name: "dollar", filePath: "out/$a.yaml", want: "out/$$a.yaml"},
+		{name: "hash", filePath: "out/#a.yaml", want: `out/\#a.yaml`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, escapeMakePath(tt.filePath))
+		})
+	}
+}
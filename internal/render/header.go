@@ -0,0 +1,253 @@
+package render
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Policies for files in formats which cannot carry comments, such as JSON.
+const (
+	// HeaderPolicyWarn skips the header, emitting a warning.
+	HeaderPolicyWarn = "warn"
+
+	// HeaderPolicySkip silently skips the header.
+	HeaderPolicySkip = "skip"
+
+	// HeaderPolicySidecar writes the header to a sidecar file alongside the file, with a `.header` suffix.
+	HeaderPolicySidecar = "sidecar"
+)
+
+// HeaderPolicies are the supported header policies.
+var HeaderPolicies = []string{HeaderPolicyWarn, HeaderPolicySkip, HeaderPolicySidecar}
+
+const headerSidecarSuffix = ".header"
+
+// commentStyle describes how to write a line comment for a file format.
+type commentStyle struct {
+	// start of each comment line
+	start string
+
+	// end of each comment line, for formats with only block comments
+	end string
+
+	// alternates are other tokens which also start a comment in this format
+	alternates []string
+}
+
+var (
+	hashCommentStyle    = commentStyle{start: "#"}
+	slashCommentStyle   = commentStyle{start: "//"}
+	dashCommentStyle    = commentStyle{start: "--"}
+	iniCommentStyle     = commentStyle{start: ";", alternates: []string{"#"}}
+	xmlCommentStyle     = commentStyle{start: "<!--", end: "-->"}
+	cssCommentStyle     = commentStyle{start: "/*", end: "*/"}
+	percentCommentStyle = commentStyle{start: "%"}
+)
+
+// commentStyles maps file extensions to the comment style of plain files.
+// Files with other extensions, including JSON, are considered unable to carry comments.
+var commentStyles = map[string]commentStyle{
+	".yaml":       hashCommentStyle,
+	".yml":        hashCommentStyle,
+	".toml":       hashCommentStyle,
+	".sh":         hashCommentStyle,
+	".bash":       hashCommentStyle,
+	".py":         hashCommentStyle,
+	".rb":         hashCommentStyle,
+	".conf":       hashCommentStyle,
+	".cfg":        hashCommentStyle,
+	".properties": hashCommentStyle,
+	".env":        hashCommentStyle,
+	".tf":         hashCommentStyle,
+	".hcl":        hashCommentStyle,
+	".mk":         hashCommentStyle,
+	".ini":        iniCommentStyle,
+	".jsonnet":    slashCommentStyle,
+	".libsonnet":  slashCommentStyle,
+	".js":         slashCommentStyle,
+	".ts":         slashCommentStyle,
+	".go":         slashCommentStyle,
+	".proto":      slashCommentStyle,
+	".sql":        dashCommentStyle,
+	".lua":        dashCommentStyle,
+	".xml":        xmlCommentStyle,
+	".html":       xmlCommentStyle,
+	".md":         xmlCommentStyle,
+	".svg":        xmlCommentStyle,
+	".css":        cssCommentStyle,
+	".tex":        percentCommentStyle,
+}
+
+// HeaderData is the data available to header templates.
+type HeaderData struct {
+	// Source is the Jsonnet entrypoint which generated the file.
+	Source string
+
+	// Path is the path of the generated file.
+	Path string
+
+	// Version is the version of jsonnet-tool.
+	Version string
+
+	// Hash is the SHA-256 of the file content, excluding the header.
+	Hash string
+}
+
+// ApplyHeaders writes the header template to the top of each file, as a comment in the
+// syntax of the file format. Files in formats which cannot carry comments are handled
+// according to the header policy, which may add sidecar files to the returned files.
+func ApplyHeaders(files []*File, options Options, warnings io.Writer) ([]*File, error) {
+	if options.Header == "" {
+		return files, nil
+	}
+
+	policy := options.HeaderPolicy
+	if policy == "" {
+		policy = HeaderPolicyWarn
+	}
+
+	if !slices.Contains(HeaderPolicies, policy) {
+		return nil, fmt.Errorf("unknown header policy %q: %w", policy, errRenderFailure)
+	}
+
+	tmpl, err := template.New("header").Option("missingkey=error").Parse(options.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header template: %w: %w", err, errRenderFailure)
+	}
+
+	result := make([]*File, 0, len(files))
+
+	var skipped []string
+
+	for _, file := range files {
+		header, err := executeHeaderTemplate(tmpl, file, options)
+		if err != nil {
+			return nil, err
+		}
+
+		style, ok := commentStyleFor(file)
+		if ok {
+			file.Content = prependHeader(file.Content, commentHeader(header, style))
+			result = append(result, file)
+
+			continue
+		}
+
+		result = append(result, file)
+
+		switch policy {
+		case HeaderPolicyWarn:
+			skipped = append(skipped, file.Path)
+		case HeaderPolicySidecar:
+			result = append(result, &File{
+				Path:    file.Path + headerSidecarSuffix,
+				Content: []byte(header + "\n"),
+				Source:  file.Source,
+				Format:  FormatPlain,
+			})
+		}
+	}
+
+	if len(skipped) > 0 {
+		slices.Sort(skipped)
+
+		_, _ = fmt.Fprintf(warnings, "warning: header not written to files which do not support comments: %s\n", strings.Join(skipped, ", "))
+	}
+
+	return result, nil
+}
+
+// commentStyleFor returns the comment style of a file. Files rendered as YAML or TOML use
+// their format's comment style whatever their extension, and plain files that of their extension.
+func commentStyleFor(file *File) (commentStyle, bool) {
+	switch file.Format {
+	case FormatYAML, FormatTOML:
+		return hashCommentStyle, true
+	case FormatJSON:
+		return commentStyle{}, false
+	}
+
+	style, ok := commentStyles[path.Ext(file.Path)]
+
+	return style, ok
+}
+
+func executeHeaderTemplate(tmpl *template.Template, file *File, options Options) (string, error) {
+	sum := sha256.Sum256(file.Content)
+
+	data := HeaderData{
+		Source:  file.Source,
+		Path:    file.Path,
+		Version: options.Version,
+		Hash:    hex.EncodeToString(sum[:]),
+	}
+
+	var buf bytes.Buffer
+
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("unable to execute header template for %s: %w: %w", file.Path, err, errRenderFailure)
+	}
+
+	return strings.TrimRight(buf.String(), "\n"), nil
+}
+
+// prependHeader writes the header at the top of the content, but after any shebang line.
+func prependHeader(content []byte, header string) []byte {
+	var buf bytes.Buffer
+
+	if bytes.HasPrefix(content, []byte("#!")) {
+		shebang, rest, _ := bytes.Cut(content, []byte("\n"))
+		buf.Write(shebang)
+		buf.WriteString("\n")
+
+		content = rest
+	}
+
+	buf.WriteString(header + "\n")
+	buf.Write(content)
+
+	return buf.Bytes()
+}
+
+// commentHeader turns each line of the header into a comment, using the comment style.
+// Lines which are already comments are left untouched.
+func commentHeader(header string, style commentStyle) string {
+	lines := strings.Split(header, "\n")
+
+	for i, line := range lines {
+		if style.isComment(line) {
+			continue
+		}
+
+		commented := strings.TrimRight(style.start+" "+line, " ")
+		if style.end != "" {
+			commented = commented + " " + style.end
+		}
+
+		lines[i] = commented
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (s commentStyle) isComment(line string) bool {
+	if strings.HasPrefix(line, s.start) {
+		return true
+	}
+
+	for _, alternate := range s.alternates {
+		if strings.HasPrefix(line, alternate) {
+			return true
+		}
+	}
+
+	return false
+}
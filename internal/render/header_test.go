@@ -0,0 +1,174 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		header       string
+		policy       string
+		files        []*File
+		want         map[string]string
+		wantWarnings string
+		wantErr      bool
+	}{
+		{
+			name:   "comment_styles",
+			header: "DO NOT EDIT",
+			files: []*File{
+				{Path: "out/a.yaml", Content: []byte("a: 1\n")},
+				{Path: "out/b.ini", Content: []byte("a = 1\n")},
+				{Path: "out/c.sql", Content: []byte("SELECT 1;\n")},
+				{Path: "out/d.html", Content: []byte("<p></p>\n")},
+			},
+			want: map[string]string{
+				"out/a.yaml": "# DO NOT EDIT\na: 1\n",
+				"out/b.ini":  "; DO NOT EDIT\na = 1\n",
+				"out/c.sql":  "-- DO NOT EDIT\nSELECT 1;\n",
+				"out/d.html": "<!-- DO NOT EDIT -->\n<p></p>\n",
+			},
+		},
+		{
+			name:   "format_overrides_extension",
+			header: "DO NOT EDIT",
+			files: []*File{
+				{Path: "out/alerts.rules", Content: []byte("a: 1\n"), Format: FormatYAML},
+				{Path: "out/plain", Content: []byte("a = 1\n"), Format: FormatTOML},
+				{Path: "out/c.yaml", Content: []byte("{}"), Format: FormatJSON},
+				{Path: "out/d.sql", Content: []byte("SELECT 1;\n"), Format: FormatPlain},
+			},
+			want: map[string]string{
+				"out/alerts.rules": "# DO NOT EDIT\na: 1\n",
+				"out/plain":        "# DO NOT EDIT\na = 1\n",
+				"out/c.yaml":       "{}",
+				"out/d.sql":        "-- DO NOT EDIT\nSELECT 1;\n",
+			},
+			wantWarnings: "warning: header not written to files which do not support comments: out/c.yaml\n",
+		},
+		{
+			name:   "existing_comments_untouched",
+			header: "# DO NOT EDIT\n\nGenerated",
+			files: []*File{
+				{Path: "out/a.yaml", Content: []byte("a: 1\n")},
+				{Path: "out/b.ini", Content: []byte("a = 1\n")},
+			},
+			want: map[string]string{
+				"out/a.yaml": "# DO NOT EDIT\n#\n# Generated\na: 1\n",
+				"out/b.ini":  "# DO NOT EDIT\n;\n; Generated\na = 1\n",
+			},
+		},
+		{
+			name:   "template",
+			header: "Generated from {{ .Source }} to {{ .Path }} by {{ .Version }}: {{ .Hash }}",
+			files: []*File{
+				{Path: "out/a.yaml", Content: []byte("a: 1\n"), Source: "a.jsonnet"},
+			},
+			want: map[string]string{
+				"out/a.yaml": "# Generated from a.jsonnet to out/a.yaml by v1.0.0: " +
+					"37b128c59f1f5097f73f82691cb519f1f568667faab5ced1b4ab979d36837eae\na: 1\n",
+			},
+		},
+		{
+			name:   "escaped_delimiters",
+			header: `Edit {{ "{{" }} .Source }} instead`,
+			files: []*File{
+				{Path: "out/a.yaml", Content: []byte("a: 1\n"), Source: "a.jsonnet"},
+			},
+			want: map[string]string{
+				"out/a.yaml": "# Edit {{ .Source }} instead\na: 1\n",
+			},
+		},
+		{
+			name:   "shebang",
+			header: "DO NOT EDIT",
+			files: []*File{
+				{Path: "out/run.sh", Content: []byte("#!/bin/sh\necho hello\n")},
+			},
+			want: map[string]string{
+				"out/run.sh": "#!/bin/sh\n# DO NOT EDIT\necho hello\n",
+			},
+		},
+		{
+			name:   "warn",
+			header: "DO NOT EDIT",
+			files: []*File{
+				{Path: "out/b.json", Content: []byte("{}")},
+				{Path: "out/a.json", Content: []byte("{}")},
+			},
+			want: map[string]string{
+				"out/a.json": "{}",
+				"out/b.json": "{}",
+			},
+			wantWarnings: "warning: header not written to files which do not support comments: out/a.json, out/b.json\n",
+		},
+		{
+			name:   "skip",
+			header: "DO NOT EDIT",
+			policy: HeaderPolicySkip,
+			files: []*File{
+				{Path: "out/a.json", Content: []byte("{}")},
+			},
+			want: map[string]string{
+				"out/a.json": "{}",
+			},
+		},
+		{
+			name:   "sidecar",
+			header: "DO NOT EDIT",
+			policy: HeaderPolicySidecar,
+			files: []*File{
+				{Path: "out/a.json", Content: []byte("{}")},
+			},
+			want: map[string]string{
+				"out/a.json":        "{}",
+				"out/a.json.header": "DO NOT EDIT\n",
+			},
+		},
+		{
+			name:    "invalid_template",
+			header:  "{{ .Unknown }}",
+			files:   []*File{{Path: "out/a.yaml"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid_policy",
+			header:  "DO NOT EDIT",
+			policy:  "explode",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var warnings bytes.Buffer
+
+			options := Options{Header: tt.header, HeaderPolicy: tt.policy, Version: "v1.0.0"}
+
+			files, err := ApplyHeaders(tt.files, options, &warnings)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got := map[string]string{}
+			for _, f := range files {
+				got[f.Path] = string(f.Content)
+			}
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantWarnings, warnings.String())
+		})
+	}
+}
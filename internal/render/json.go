@@ -1,16 +1,105 @@
 package render
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"io"
+	"strings"
 )
 
 var errRenderFailure = errors.New("render failed")
 
-func writeStringData(f *os.File, data string) error {
-	_, err := f.WriteString(data)
+const defaultJSONIndent = 2
+
+// JSONStyle controls the formatting of JSON output.
+type JSONStyle struct {
+	// Indent is the number of spaces used for indentation. Defaults to 2.
+	Indent int
+
+	// Compact emits JSON without any indentation or whitespace between values, ignoring Indent.
+	Compact bool
+
+	// TrailingNewline ends the output with a newline.
+	TrailingNewline bool
+
+	// NoHTMLEscape writes <, > and & within strings as-is, rather than as \u escapes.
+	NoHTMLEscape bool
+
+	// PreserveNumbers keeps fractional numbers as formatted by Jsonnet, rather than reformatting
+	// them as Go floats. Integers decoded as json.Number values are always written as-is.
+	PreserveNumbers bool
+}
+
+func (s JSONStyle) indent() string {
+	if s.Compact {
+		return ""
+	}
+
+	if s.Indent == 0 {
+		return strings.Repeat(" ", defaultJSONIndent)
+	}
+
+	return strings.Repeat(" ", s.Indent)
+}
+
+// DecodeJSON decodes the JSON output of a Jsonnet evaluation into v, keeping numbers as json.Number
+// values, so that they are not rounded to a float64 and can be written exactly as Jsonnet formatted them.
+func DecodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	err := decoder.Decode(v)
+	if err != nil {
+		return fmt.Errorf("decode failed: %w: %w", err, errRenderFailure)
+	}
+
+	return nil
+}
+
+// isJSONInteger reports whether a number is written as an integer, without a fraction or exponent.
+func isJSONInteger(n json.Number) bool {
+	return !strings.ContainsAny(n.String(), ".eE")
+}
+
+// jsonNumbers prepares a value for JSON encoding. Integers are written as-is, so that integers beyond
+// the precision of a float64 are not rounded. Unless they are preserved, other numbers are converted
+// to float64, so that they are formatted by Go, such as 0.1 rather than Jsonnet's 0.10000000000000001.
+func jsonNumbers(v interface{}, style JSONStyle) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if style.PreserveNumbers || isJSONInteger(value) {
+			return value
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			return value
+		}
+
+		return f
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			r[k] = jsonNumbers(item, style)
+		}
+
+		return r
+	case []interface{}:
+		r := make([]interface{}, len(value))
+		for i, item := range value {
+			r[i] = jsonNumbers(item, style)
+		}
+
+		return r
+	default:
+		return value
+	}
+}
+
+func writeStringData(w io.Writer, data string) error {
+	_, err := io.WriteString(w, data)
 	if err != nil {
 		return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
 	}
@@ -18,13 +107,29 @@ func writeStringData(f *os.File, data string) error {
 	return nil
 }
 
-func writeJSONData(f *os.File, data interface{}) error {
-	marshalled, err := json.MarshalIndent(data, "", "  ")
+func writeJSONData(w io.Writer, data interface{}, style JSONStyle) error {
+	if style.Indent < 0 {
+		return fmt.Errorf("invalid JSON indent %d: %w", style.Indent, errRenderFailure)
+	}
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", style.indent())
+	encoder.SetEscapeHTML(!style.NoHTMLEscape)
+
+	err := encoder.Encode(jsonNumbers(data, style))
 	if err != nil {
 		return fmt.Errorf("marshal failed: %w: %w", err, errRenderFailure)
 	}
 
-	_, err = f.Write(marshalled)
+	// The encoder always ends the value with a newline
+	marshalled := buf.Bytes()
+	if !style.TrailingNewline {
+		marshalled = bytes.TrimSuffix(marshalled, []byte("\n"))
+	}
+
+	_, err = w.Write(marshalled)
 	if err != nil {
 		return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
 	}
@@ -33,28 +138,57 @@ func writeJSONData(f *os.File, data interface{}) error {
 }
 
 // JSONData renders data, either as a string or in JSON format.
-func JSONData(filenameKey string, data interface{}, options Options) error {
-	f, filePath, err := openFileForRender(filenameKey, options)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+func JSONData(filenameKey string, data interface{}, options Options) (*File, error) {
+	var buf bytes.Buffer
+
+	format := FormatJSON
 
 	switch v := data.(type) {
 	case string:
-		err = writeStringData(f, v)
+		format = FormatPlain
+
+		err := writeStringData(&buf, v)
 		if err != nil {
-			return fmt.Errorf("failed to write string data: %w: %w", err, errRenderFailure)
+			return nil, fmt.Errorf("failed to write string data: %w: %w", err, errRenderFailure)
 		}
 
 	default:
-		err = writeJSONData(f, v)
+		err := writeJSONData(&buf, v, options.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write JSON data: %w: %w", err, errRenderFailure)
+		}
+	}
+
+	return &File{Path: outputPathForRender(filenameKey, options), Content: buf.Bytes(), Format: format}, nil
+}
+
+// FormatValue formats the JSON output of a Jsonnet evaluation for display, ending with a newline.
+// When format is FormatYAML, the value is written as a YAML document, and otherwise as indented JSON.
+func FormatValue(output string, format string, options Options) ([]byte, error) {
+	if format == FormatYAML {
+		var data interface{}
+
+		err := DecodeJSON([]byte(output), &data)
 		if err != nil {
-			return fmt.Errorf("failed to write JSON data: %w: %w", err, errRenderFailure)
+			return nil, fmt.Errorf("failed to unmarshal json data: %w", err)
 		}
+
+		content, err := YAMLValue(data, options)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render YAML: %w", err)
+		}
+
+		return content, nil
 	}
 
-	fmt.Println(filePath)
+	var buf bytes.Buffer
 
-	return nil
+	err := json.Indent(&buf, []byte(strings.TrimSpace(output)), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to format json data: %w: %w", err, errRenderFailure)
+	}
+
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
 }
@@ -0,0 +1,130 @@
+package render
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		data       interface{}
+		style      JSONStyle
+		want       string
+		wantFormat string
+	}{
+		{
+			name:       "default",
+			data:       map[string]interface{}{"a": []interface{}{1.0, "<b>"}},
+			want:       "{\n  \"a\": [\n    1,\n    \"\\u003cb\\u003e\"\n  ]\n}",
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "indent",
+			data:       map[string]interface{}{"a": 1.0},
+			style:      JSONStyle{Indent: 4, TrailingNewline: true},
+			want:       "{\n    \"a\": 1\n}\n",
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "compact_ignores_indent",
+			data:       map[string]interface{}{"a": []interface{}{1.0, 2.0}},
+			style:      JSONStyle{Indent: 4, Compact: true},
+			want:       `{"a":[1,2]}`,
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "no_html_escape",
+			data:       map[string]interface{}{"a": "<b> & <c>"},
+			style:      JSONStyle{Compact: true, NoHTMLEscape: true},
+			want:       `{"a":"<b> & <c>"}`,
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "numbers",
+			data:       map[string]interface{}{"a": json.Number("0.10000000000000001"), "b": json.Number("1000000000000000000000")},
+			style:      JSONStyle{Compact: true, PreserveNumbers: true},
+			want:       `{"a":0.10000000000000001,"b":1000000000000000000000}`,
+			wantFormat: FormatJSON,
+		},
+		{
+			name: "integers_are_exact",
+			data: map[string]interface{}{
+				"maxInt64":  json.Number("9223372036854775807"),
+				"minInt64":  json.Number("-9223372036854775808"),
+				"maxUint64": json.Number("18446744073709551615"),
+				"fraction":  json.Number("0.10000000000000001"),
+				"float":     0.5,
+			},
+			style:      JSONStyle{Compact: true},
+			want:       `{"float":0.5,"fraction":0.1,"maxInt64":9223372036854775807,"maxUint64":18446744073709551615,"minInt64":-9223372036854775808}`,
+			wantFormat: FormatJSON,
+		},
+		{
+			name:       "string",
+			data:       "<plain>",
+			style:      JSONStyle{TrailingNewline: true},
+			want:       "<plain>",
+			wantFormat: FormatPlain,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := JSONData("file.json", tt.data, Options{JSON: tt.style})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(file.Content))
+			assert.Equal(t, tt.wantFormat, file.Format)
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+
+	output := "{\n   \"b\": 12345678901234567890,\n   \"a\": [1.5]\n}\n"
+
+	tests := []struct {
+		name    string
+		format  string
+		options Options
+		want    string
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			want:   "{\n  \"b\": 12345678901234567890,\n  \"a\": [\n    1.5\n  ]\n}\n",
+		},
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			want:   "a:\n- 1.5\nb: 12345678901234567890\n",
+		},
+		{
+			name:    "yaml_priority_keys",
+			format:  FormatYAML,
+			options: Options{PriorityKeys: []string{"b"}},
+			want:    "b: 12345678901234567890\na:\n- 1.5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := FormatValue(output, tt.format, tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+
+	_, err := FormatValue("{", FormatJSON, Options{})
+	require.ErrorContains(t, err, "failed to format json data")
+}
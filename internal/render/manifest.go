@@ -0,0 +1,261 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const manifestFileName = ".jsonnet-tool-manifest.json"
+
+// Manifest records the files generated into an output directory, keyed by the
+// entrypoint which generated them. This allows files which are no longer generated
+// to be pruned, without ever touching files that the tool did not create.
+// All paths are relative to the output directory.
+type Manifest struct {
+	Sources map[string][]string `json:"sources"`
+
+	dir    string
+	exists bool
+}
+
+// LoadManifest loads the manifest for the output directory. If the directory does
+// not yet have a manifest, an empty manifest is returned.
+func LoadManifest(dir string) (*Manifest, error) {
+	m := &Manifest{
+		Sources: map[string][]string{},
+		dir:     dir,
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return m, nil
+		}
+
+		return nil, fmt.Errorf("failed to read manifest: %w: %w", err, errRenderFailure)
+	}
+
+	err = json.Unmarshal(b, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w: %w", manifestFileName, err, errRenderFailure)
+	}
+
+	if m.Sources == nil {
+		m.Sources = map[string][]string{}
+	}
+
+	m.exists = true
+
+	return m, nil
+}
+
+// Exists returns true if the manifest was loaded from disk.
+func (m *Manifest) Exists() bool {
+	return m.exists
+}
+
+// Stale returns the paths of files previously generated by the sources of the
+// given files, which are no longer generated.
+func (m *Manifest) Stale(files []*File) ([]string, error) {
+	generated, bySource, err := m.relativePaths(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+
+	for source := range bySource {
+		for _, rel := range m.Sources[source] {
+			if _, ok := generated[rel]; ok {
+				continue
+			}
+
+			// Never follow entries which would escape the output directory
+			if !filepath.IsLocal(filepath.FromSlash(rel)) {
+				continue
+			}
+
+			stale = append(stale, filepath.Join(m.dir, filepath.FromSlash(rel)))
+		}
+	}
+
+	slices.Sort(stale)
+
+	return slices.Compact(stale), nil
+}
+
+// Record updates the manifest with the files generated for each source. Files in
+// retained were previously generated by one of the sources and have not been pruned,
+// so the tool retains ownership of them.
+func (m *Manifest) Record(files []*File, retained []string) error {
+	_, bySource, err := m.relativePaths(files)
+	if err != nil {
+		return err
+	}
+
+	retainedSet := map[string]struct{}{}
+
+	for _, filePath := range retained {
+		rel, err := m.relativePath(filePath)
+		if err != nil {
+			return err
+		}
+
+		retainedSet[rel] = struct{}{}
+	}
+
+	for source, rels := range bySource {
+		for _, rel := range m.Sources[source] {
+			if _, ok := retainedSet[rel]; ok {
+				rels = append(rels, rel)
+			}
+		}
+
+		slices.Sort(rels)
+		m.Sources[source] = slices.Compact(rels)
+	}
+
+	return nil
+}
+
+// Save writes the manifest to the output directory.
+func (m *Manifest) Save() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal manifest: %w: %w", err, errRenderFailure)
+	}
+
+	err = os.MkdirAll(m.dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to MkdirAll for %s: %w", m.dir, err)
+	}
+
+	// Write the manifest to a temporary file and rename it into place, so that
+	// a failure never leaves a truncated manifest
+	f, err := os.CreateTemp(m.dir, manifestFileName+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w: %w", err, errRenderFailure)
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w: %w", err, errRenderFailure)
+	}
+
+	// Temporary files are created readable only by their owner, unlike the generated files
+	err = f.Chmod(0644)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w: %w", err, errRenderFailure)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w: %w", err, errRenderFailure)
+	}
+
+	err = os.Rename(f.Name(), filepath.Join(m.dir, manifestFileName))
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w: %w", err, errRenderFailure)
+	}
+
+	m.exists = true
+
+	return nil
+}
+
+// relativePaths returns the set of generated paths and the generated paths grouped by source.
+func (m *Manifest) relativePaths(files []*File) (map[string]struct{}, map[string][]string, error) {
+	generated := map[string]struct{}{}
+	bySource := map[string][]string{}
+
+	for _, file := range files {
+		source, err := m.sourceKey(file.Source)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rel, err := m.relativePath(file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		generated[rel] = struct{}{}
+		bySource[source] = append(bySource[source], rel)
+	}
+
+	return generated, bySource, nil
+}
+
+func (m *Manifest) relativePath(filePath string) (string, error) {
+	rel, err := filepath.Rel(m.dir, filePath)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine relative path for %s: %w: %w", filePath, err, errRenderFailure)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// sourceKey returns the path of the source entrypoint relative to the output directory,
+// so that the key is stable regardless of the working directory.
+func (m *Manifest) sourceKey(source string) (string, error) {
+	absDir, err := filepath.Abs(m.dir)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s: %w: %w", m.dir, err, errRenderFailure)
+	}
+
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve %s: %w: %w", source, err, errRenderFailure)
+	}
+
+	rel, err := filepath.Rel(absDir, absSource)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine relative path for %s: %w: %w", source, err, errRenderFailure)
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// PruneFiles removes stale files, along with any directories left empty within the
+// output directory, listing each pruned file on out.
+func PruneFiles(stale []string, options Options, out io.Writer) error {
+	root := filepath.Clean(options.MultiDir)
+
+	for _, filePath := range stale {
+		err := os.Remove(filePath)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return fmt.Errorf("unable to prune %s: %w: %w", filePath, err, errRenderFailure)
+		}
+
+		_, _ = fmt.Fprintf(out, "pruned %s\n", filePath)
+
+		// Remove any parent directories which are now empty, stopping at the output directory
+		for dir := filepath.Dir(filePath); isWithin(root, dir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// isWithin returns true if dir is a directory within root, excluding root itself.
+func isWithin(root string, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+
+	return err == nil && rel != "." && filepath.IsLocal(rel)
+}
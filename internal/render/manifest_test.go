@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestSave(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	m, err := LoadManifest(dir)
+	require.NoError(t, err)
+
+	m.Sources["a.jsonnet"] = []string{"a.yaml"}
+	require.NoError(t, m.Save())
+
+	info, err := os.Stat(filepath.Join(dir, manifestFileName))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	loaded, err := LoadManifest(dir)
+	require.NoError(t, err)
+	assert.True(t, loaded.Exists())
+	assert.Equal(t, m.Sources, loaded.Sources)
+}
+
+// TestPruneFilesWorkingDirectory prunes files from the working directory, as with --multi .,
+// whose empty subdirectories are removed however short their names. It changes the working
+// directory, so cannot run in parallel.
+func TestPruneFilesWorkingDirectory(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.Chdir(dir))
+
+	t.Cleanup(func() {
+		require.NoError(t, os.Chdir(wd))
+	})
+
+	files := []string{
+		filepath.Join("a", "a.yaml"),
+		filepath.Join("b", "c", "b.yaml"),
+		filepath.Join("kept", "removed.yaml"),
+		filepath.Join("kept", "kept.yaml"),
+	}
+
+	for _, file := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte("a: 1\n"), 0644))
+	}
+
+	var out bytes.Buffer
+
+	require.NoError(t, PruneFiles(files[:3], Options{MultiDir: "."}, &out))
+
+	for _, removed := range []string{"a", "b", files[2]} {
+		assert.NoFileExists(t, removed)
+		assert.NoDirExists(t, removed)
+	}
+
+	assert.FileExists(t, files[3])
+	assert.DirExists(t, dir)
+	assert.Equal(t, "pruned "+files[0]+"\npruned "+files[1]+"\npruned "+files[2]+"\n", out.String())
+}
@@ -4,6 +4,17 @@ package render
 type Options struct {
 	MultiDir       string
 	FilenamePrefix string
-	Header         string
 	PriorityKeys   []string
+	YAML           YAMLStyle
+	JSON           JSONStyle
+
+	// Header is a template for a header written to the top of each file,
+	// see HeaderData for the available fields.
+	Header string
+
+	// HeaderPolicy controls how headers are handled for files which cannot carry comments.
+	HeaderPolicy string
+
+	// Version is the version of jsonnet-tool, available to header templates.
+	Version string
 }
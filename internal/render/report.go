@@ -0,0 +1,80 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	// OutputFormatText lists the path of each generated file, one per line.
+	OutputFormatText = "text"
+
+	// OutputFormatJSON emits a JSON document describing each generated file.
+	OutputFormatJSON = "json"
+)
+
+// OutputFormats are the supported formats for WriteReport.
+var OutputFormats = []string{OutputFormatText, OutputFormatJSON}
+
+type fileReport struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+	Format string `json:"format"`
+}
+
+type report struct {
+	Files []fileReport `json:"files"`
+}
+
+// WriteReport describes the generated files to w, sorted by path, in the given output format.
+func WriteReport(w io.Writer, files []*File, outputFormat string) error {
+	sorted := slices.Clone(files)
+	slices.SortFunc(sorted, func(a, b *File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	switch outputFormat {
+	case OutputFormatText:
+		for _, file := range sorted {
+			_, err := fmt.Fprintln(w, file.Path)
+			if err != nil {
+				return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
+			}
+		}
+
+		return nil
+	case OutputFormatJSON:
+		r := report{Files: make([]fileReport, 0, len(sorted))}
+
+		for _, file := range sorted {
+			sum := sha256.Sum256(file.Content)
+
+			r.Files = append(r.Files, fileReport{
+				Path:   file.Path,
+				Source: file.Source,
+				Size:   len(file.Content),
+				SHA256: hex.EncodeToString(sum[:]),
+				Format: file.Format,
+			})
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		err := encoder.Encode(r)
+		if err != nil {
+			return fmt.Errorf("encode failed: %w: %w", err, errRenderFailure)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q: %w", outputFormat, errRenderFailure)
+	}
+}
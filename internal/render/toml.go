@@ -0,0 +1,108 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLData will render data as a TOML file. Strings are assumed to be pre-manifested TOML,
+// and are written as-is.
+func TOMLData(filenameKey string, data interface{}, options Options) (*File, error) {
+	var buf bytes.Buffer
+
+	switch v := data.(type) {
+	case string:
+		buf.WriteString(v)
+	case map[string]interface{}:
+		table, err := tomlValue(v, "")
+		if err != nil {
+			return nil, err
+		}
+
+		err = toml.NewEncoder(&buf).Encode(table)
+		if err != nil {
+			return nil, fmt.Errorf("encode failed: %w: %w", err, errRenderFailure)
+		}
+	default:
+		return nil, fmt.Errorf("TOML documents must be an object or a string, got %T: %w", v, errRenderFailure)
+	}
+
+	return &File{Path: outputPathForRender(filenameKey, options), Content: buf.Bytes(), Format: FormatTOML}, nil
+}
+
+// tomlValue prepares a value decoded from JSON for TOML encoding. TOML distinguishes
+// between integers and floats, so whole numbers, whether json.Number or float64 values,
+// are converted to integers.
+// TOML has no null value, so nulls are rejected, and integers beyond the range of a TOML
+// integer are rejected rather than being rounded to a float.
+func tomlValue(v interface{}, key string) (interface{}, error) {
+	switch value := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("TOML does not support null values, found at key `%s`: %w", key, errRenderFailure)
+	case json.Number:
+		i, err := value.Int64()
+		if err == nil {
+			return i, nil
+		}
+
+		if isJSONInteger(value) {
+			return nil, fmt.Errorf(
+				"integer %s at key `%s` is beyond the range of a TOML integer, a 64-bit signed integer: %w",
+				value, key, errRenderFailure,
+			)
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at key `%s`: %w", value, key, errRenderFailure)
+		}
+
+		return tomlValue(f, key)
+	case float64:
+		if value == math.Trunc(value) && value >= math.MinInt64 && value < math.MaxInt64 {
+			return int64(value), nil
+		}
+
+		return value, nil
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(value))
+
+		for k, item := range value {
+			converted, err := tomlValue(item, joinTOMLKey(key, k))
+			if err != nil {
+				return nil, err
+			}
+
+			r[k] = converted
+		}
+
+		return r, nil
+	case []interface{}:
+		r := make([]interface{}, 0, len(value))
+
+		for i, item := range value {
+			converted, err := tomlValue(item, fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+
+			r = append(r, converted)
+		}
+
+		return r, nil
+	default:
+		return value, nil
+	}
+}
+
+func joinTOMLKey(parent string, key string) string {
+	if parent == "" {
+		return key
+	}
+
+	return parent + "." + key
+}
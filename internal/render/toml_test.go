@@ -0,0 +1,103 @@
+package render
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOMLData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "tables_and_arrays_of_tables",
+			data: map[string]interface{}{
+				"title": "example",
+				"port":  8080.0,
+				"ratio": 0.5,
+				"database": map[string]interface{}{
+					"enabled": true,
+					"ports":   []interface{}{8000.0, 8001.0},
+				},
+				"servers": []interface{}{
+					map[string]interface{}{"name": "alpha"},
+					map[string]interface{}{"name": "beta"},
+				},
+			},
+			want: `port = 8080
+ratio = 0.5
+title = "example"
+
+[database]
+  enabled = true
+  ports = [8000, 8001]
+
+[[servers]]
+  name = "alpha"
+
+[[servers]]
+  name = "beta"
+`,
+		},
+		{
+			name: "pre_manifested",
+			data: "a = 1\n",
+			want: "a = 1\n",
+		},
+		{
+			name: "json_numbers",
+			data: map[string]interface{}{
+				"max":      json.Number("9223372036854775807"),
+				"min":      json.Number("-9223372036854775808"),
+				"ratio":    json.Number("0.5"),
+				"exponent": json.Number("1e+21"),
+			},
+			want: "exponent = 1000000000000000000000.0\nmax = 9223372036854775807\nmin = -9223372036854775808\nratio = 0.5\n",
+		},
+		{
+			name:    "integer_overflow",
+			data:    map[string]interface{}{"a": map[string]interface{}{"b": json.Number("9223372036854775808")}},
+			wantErr: true,
+		},
+		{
+			name:    "negative_integer_overflow",
+			data:    map[string]interface{}{"a": json.Number("-9223372036854775809")},
+			wantErr: true,
+		},
+		{
+			name:    "null",
+			data:    map[string]interface{}{"a": map[string]interface{}{"b": nil}},
+			wantErr: true,
+		},
+		{
+			name:    "not_an_object",
+			data:    []interface{}{1.0},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := TOMLData("file.toml", tt.data, Options{MultiDir: "out"})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(file.Content))
+			assert.Equal(t, "out/file.toml", file.Path)
+			assert.Equal(t, FormatTOML, file.Format)
+		})
+	}
+}
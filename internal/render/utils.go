@@ -1,26 +1,204 @@
 package render
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 )
 
-func openFileForRender(filename string, options Options) (*os.File, string, error) {
+// File is a rendered file, ready to be written to disk.
+type File struct {
+	// Path is the destination of the file, including the output directory and filename prefix.
+	Path    string
+	Content []byte
+
+	// Source is the Jsonnet entrypoint which generated the file.
+	Source string
+
+	// Format is the format of the file content, one of FormatJSON, FormatYAML, FormatTOML or FormatPlain.
+	Format string
+}
+
+// Formats of rendered file content.
+const (
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatTOML  = "toml"
+	FormatPlain = "plain"
+)
+
+func outputPathForRender(filename string, options Options) string {
 	filePath := path.Join(options.MultiDir, filename)
 	fileDir := path.Dir(filePath)
 	fileBase := path.Base(filePath)
-	filePathWithPrefix := path.Join(fileDir, options.FilenamePrefix+fileBase)
 
-	err := os.MkdirAll(fileDir, os.ModePerm)
+	return path.Join(fileDir, options.FilenamePrefix+fileBase)
+}
+
+// stageFile writes the content of a file to the staging path.
+// If the destination already exists, its permissions are retained.
+func stageFile(file *File, stagingPath string) error {
+	f, err := os.OpenFile(stagingPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return fmt.Errorf("unable to create file: %w: %w", err, errRenderFailure)
+	}
+
+	defer f.Close()
+
+	_, err = f.Write(file.Content)
+	if err != nil {
+		return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
+	}
+
+	info, err := os.Stat(file.Path)
+	if err == nil {
+		err = f.Chmod(info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("unable to set permissions: %w: %w", err, errRenderFailure)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("unable to stat %s: %w: %w", file.Path, err, errRenderFailure)
+	}
+
+	err = f.Close()
 	if err != nil {
-		return nil, "", fmt.Errorf("unable to MkdirAll for %s: %w", fileDir, err)
+		return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
 	}
 
-	f, err := os.Create(filePathWithPrefix)
+	return nil
+}
+
+// WriteFiles writes rendered files to disk.
+// Every file is first staged into a temporary directory within the output directory,
+// and only once all files have been staged successfully are they renamed into place,
+// so that a failure never leaves the output directory partially written.
+func WriteFiles(files []*File, options Options) error {
+	return writeFiles(files, options, os.Rename)
+}
+
+// writeFiles implements WriteFiles, moving files with rename, so that tests can
+// make moving a file fail.
+func writeFiles(files []*File, options Options, rename func(oldPath, newPath string) error) error {
+	err := os.MkdirAll(options.MultiDir, os.ModePerm)
 	if err != nil {
-		return nil, "", fmt.Errorf("unable to create file: %w: %w", err, errRenderFailure)
+		return fmt.Errorf("unable to MkdirAll for %s: %w", options.MultiDir, err)
+	}
+
+	// Staging in the output directory ensures that renames do not cross filesystems
+	stagingDir, err := os.MkdirTemp(options.MultiDir, ".jsonnet-tool-staging-")
+	if err != nil {
+		return fmt.Errorf("unable to create staging directory: %w: %w", err, errRenderFailure)
+	}
+
+	defer os.RemoveAll(stagingDir)
+
+	stagingPaths := make([]string, len(files))
+
+	for i, file := range files {
+		stagingPaths[i] = filepath.Join(stagingDir, strconv.Itoa(i))
+
+		err = stageFile(file, stagingPaths[i])
+		if err != nil {
+			return fmt.Errorf("unable to stage %s: %w", file.Path, err)
+		}
+	}
+
+	err = prepareDestinations(files)
+	if err != nil {
+		return err
+	}
+
+	return commitFiles(files, stagingPaths, rename)
+}
+
+// prepareDestinations creates the directory of every file, and checks that no file
+// would replace a directory, so that moving files into place is unlikely to fail.
+func prepareDestinations(files []*File) error {
+	for _, file := range files {
+		fileDir := path.Dir(file.Path)
+
+		err := os.MkdirAll(fileDir, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("unable to MkdirAll for %s: %w", fileDir, err)
+		}
+
+		info, err := os.Lstat(file.Path)
+		if err == nil && info.IsDir() {
+			return fmt.Errorf("unable to write %s: destination is a directory: %w", file.Path, errRenderFailure)
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("unable to stat %s: %w: %w", file.Path, err, errRenderFailure)
+		}
+	}
+
+	return nil
+}
+
+// commitFiles moves staged files into place. Existing files are first moved aside,
+// alongside the staged files, so that they can be restored if any move fails.
+func commitFiles(files []*File, stagingPaths []string, rename func(oldPath, newPath string) error) error {
+	// originals are the paths to which replaced files were moved, or empty for new files
+	originals := make([]string, 0, len(files))
+
+	for i, file := range files {
+		original := ""
+
+		_, err := os.Lstat(file.Path)
+		if err == nil {
+			original = stagingPaths[i] + ".orig"
+
+			err = rename(file.Path, original)
+			if err != nil {
+				rollbackFiles(files, originals, rename)
+				return fmt.Errorf("unable to move %s aside: %w: %w", file.Path, err, errRenderFailure)
+			}
+		}
+
+		originals = append(originals, original)
+
+		err = rename(stagingPaths[i], file.Path)
+		if err != nil {
+			rollbackFiles(files, originals, rename)
+			return fmt.Errorf("unable to move %s into place: %w: %w", file.Path, err, errRenderFailure)
+		}
+	}
+
+	return nil
+}
+
+// rollbackFiles restores the files replaced by commitFiles, and removes the files it added,
+// in reverse order. Errors are ignored, as the failure which caused the rollback is reported.
+func rollbackFiles(files []*File, originals []string, rename func(oldPath, newPath string) error) {
+	for i := len(originals) - 1; i >= 0; i-- {
+		if originals[i] == "" {
+			_ = os.Remove(files[i].Path)
+			continue
+		}
+
+		_ = rename(originals[i], files[i].Path)
+	}
+}
+
+// CheckDuplicatePaths returns an error if more than one file would be written to the same path.
+func CheckDuplicatePaths(files []*File) error {
+	sources := map[string]string{}
+
+	var errs []error
+
+	for _, file := range files {
+		filePath := filepath.Clean(file.Path)
+
+		source, ok := sources[filePath]
+		if ok {
+			errs = append(errs, fmt.Errorf("%s is generated by both %s and %s: %w", filePath, source, file.Source, errRenderFailure))
+			continue
+		}
+
+		sources[filePath] = file.Source
 	}
 
-	return f, filePathWithPrefix, nil
+	return errors.Join(errs...)
 }
@@ -0,0 +1,103 @@
+package render
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	options := Options{MultiDir: dir}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte("old"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blocker"), []byte("not a directory"), 0644))
+
+	t.Run("failure leaves output untouched", func(t *testing.T) {
+		err := WriteFiles([]*File{
+			{Path: filepath.Join(dir, "a.json"), Content: []byte("new")},
+			{Path: filepath.Join(dir, "blocker", "b.json"), Content: []byte("new")},
+		}, options)
+		require.Error(t, err)
+
+		assertFileContent(t, filepath.Join(dir, "a.json"), "old")
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "staging directory should be removed")
+	})
+
+	t.Run("destination directory leaves output untouched", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "d.json"), os.ModePerm))
+		defer os.Remove(filepath.Join(dir, "d.json"))
+
+		err := WriteFiles([]*File{
+			{Path: filepath.Join(dir, "a.json"), Content: []byte("new")},
+			{Path: filepath.Join(dir, "d.json"), Content: []byte("new")},
+		}, options)
+		require.ErrorContains(t, err, "destination is a directory")
+
+		assertFileContent(t, filepath.Join(dir, "a.json"), "old")
+	})
+
+	t.Run("failure moving into place restores output", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte("old"), 0644))
+		defer os.Remove(filepath.Join(dir, "c.json"))
+
+		// Fails to move the last file into place, once the others have been replaced
+		failed := false
+		rename := func(oldPath, newPath string) error {
+			if !failed && newPath == filepath.Join(dir, "c.json") {
+				failed = true
+				return errors.New("rename failed")
+			}
+
+			return os.Rename(oldPath, newPath)
+		}
+
+		err := writeFiles([]*File{
+			{Path: filepath.Join(dir, "a.json"), Content: []byte("new")},
+			{Path: filepath.Join(dir, "b", "b.json"), Content: []byte("new")},
+			{Path: filepath.Join(dir, "c.json"), Content: []byte("new")},
+		}, options, rename)
+		require.ErrorContains(t, err, "rename failed")
+
+		assertFileContent(t, filepath.Join(dir, "a.json"), "old")
+		assertFileContent(t, filepath.Join(dir, "c.json"), "old")
+		assert.NoFileExists(t, filepath.Join(dir, "b", "b.json"))
+		require.NoError(t, os.Remove(filepath.Join(dir, "b")))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3, "staging directory should be removed")
+	})
+
+	t.Run("success replaces files and retains permissions", func(t *testing.T) {
+		err := WriteFiles([]*File{
+			{Path: filepath.Join(dir, "a.json"), Content: []byte("new")},
+			{Path: filepath.Join(dir, "x", "y", "c.json"), Content: []byte("new")},
+		}, options)
+		require.NoError(t, err)
+
+		assertFileContent(t, filepath.Join(dir, "a.json"), "new")
+		assertFileContent(t, filepath.Join(dir, "x", "y", "c.json"), "new")
+
+		info, err := os.Stat(filepath.Join(dir, "a.json"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+}
+
+func assertFileContent(t *testing.T, filePath string, want string) {
+	t.Helper()
+
+	b, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(b))
+}
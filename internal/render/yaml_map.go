@@ -1,34 +1,67 @@
 package render
 
 import (
-	"fmt"
+	"encoding/json"
+	"strconv"
 
-	yaml "gopkg.in/yaml.v2"
+	yamlcmd "gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/cmd/yaml"
 )
 
+// yamlMapDocument orders the keys of the map according to the priority keys.
+func yamlMapDocument(data map[string]interface{}, options Options) interface{} {
+	m := make(map[interface{}]interface{}, len(data))
+	for k, v := range data {
+		m[k] = yamlNumbers(v)
+	}
+
+	return yamlcmd.ReorderKeys(m, options.PriorityKeys)
+}
+
 // YAMLMapData will render a map as a YAML file.
-func YAMLMapData(filenameKey string, data map[string]interface{}, options Options) error {
-	f, filePath, err := openFileForRender(filenameKey, options)
+func YAMLMapData(filenameKey string, data map[string]interface{}, options Options) (*File, error) {
+	content, err := encodeYAMLDocuments([]interface{}{yamlMapDocument(data, options)}, options)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
 
-	if options.Header != "" {
-		_, err = f.WriteString(options.Header + "\n")
-		if err != nil {
-			return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
-		}
-	}
+	return &File{Path: outputPathForRender(filenameKey, options), Content: content, Format: FormatYAML}, nil
+}
 
-	encoder := yaml.NewEncoder(f)
+// YAMLValue encodes any value as a single YAML document, ordering the keys of the objects
+// within it according to the priority keys.
+func YAMLValue(data interface{}, options Options) ([]byte, error) {
+	return encodeYAMLDocuments([]interface{}{yamlcmd.ReorderValue(yamlNumbers(data), options.PriorityKeys)}, options)
+}
 
-	err = encoder.Encode(data)
-	if err != nil {
-		return fmt.Errorf("encode failure: %w: %w", err, errRenderFailure)
-	}
+// yamlNumbers prepares a value for YAML encoding. The YAML encoder writes json.Number values within
+// the range of an int64 as integers, and any others as floats, so larger integers within the range
+// of a uint64 are converted to uint64 to keep them exact.
+func yamlNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if isJSONInteger(value) {
+			u, err := strconv.ParseUint(value.String(), 10, 64)
+			if err == nil {
+				return u
+			}
+		}
 
-	fmt.Println(filePath)
+		return value
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			r[k] = yamlNumbers(item)
+		}
 
-	return nil
+		return r
+	case []interface{}:
+		r := make([]interface{}, len(value))
+		for i, item := range value {
+			r[i] = yamlNumbers(item)
+		}
+
+		return r
+	default:
+		return value
+	}
 }
@@ -0,0 +1,39 @@
+package render
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLMapDataNumbers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		number json.Number
+		want   string
+	}{
+		{name: "float64_precision", number: "9007199254740993", want: "9007199254740993"},
+		{name: "max_int64", number: "9223372036854775807", want: "9223372036854775807"},
+		{name: "min_int64", number: "-9223372036854775808", want: "-9223372036854775808"},
+		{name: "beyond_int64", number: "9223372036854775808", want: "9223372036854775808"},
+		{name: "max_uint64", number: "18446744073709551615", want: "18446744073709551615"},
+		{name: "beyond_uint64", number: "18446744073709551616", want: "1.8446744073709552e+19"},
+		{name: "below_min_int64", number: "-9223372036854775809", want: "-9.223372036854776e+18"},
+		{name: "whole", number: "1000000", want: "1000000"},
+		{name: "fraction", number: "0.10000000000000001", want: "0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := YAMLMapData("file.yaml", map[string]interface{}{"v": []interface{}{tt.number}}, Options{})
+			require.NoError(t, err)
+			assert.Equal(t, "v:\n- "+tt.want+"\n", string(file.Content))
+		})
+	}
+}
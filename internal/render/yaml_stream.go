@@ -0,0 +1,34 @@
+package render
+
+import (
+	"fmt"
+)
+
+// YAMLStreamData will render an array as a YAML stream, with one document per element.
+// Each element may either be an object, or a pre-manifested YAML string.
+func YAMLStreamData(filenameKey string, data []interface{}, options Options) (*File, error) {
+	documents := make([]interface{}, 0, len(data))
+
+	for i, element := range data {
+		switch v := element.(type) {
+		case string:
+			document, err := yamlStringDocument(v, options)
+			if err != nil {
+				return nil, err
+			}
+
+			documents = append(documents, document)
+		case map[string]interface{}:
+			documents = append(documents, yamlMapDocument(v, options))
+		default:
+			return nil, fmt.Errorf("unexpected type in YAML stream at index %d: %T: %w", i, v, errRenderFailure)
+		}
+	}
+
+	content, err := encodeYAMLDocuments(documents, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{Path: outputPathForRender(filenameKey, options), Content: content, Format: FormatYAML}, nil
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLStreamData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    []interface{}
+		options Options
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "objects",
+			data: []interface{}{
+				map[string]interface{}{"kind": "ConfigMap", "apiVersion": "v1"},
+				map[string]interface{}{"kind": "Secret", "apiVersion": "v1"},
+			},
+			want: "apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nkind: Secret\n",
+		},
+		{
+			name: "pre_manifested_with_priority_keys",
+			data: []interface{}{
+				"b: 1\nname: first\n",
+				map[string]interface{}{"a": 1.0},
+			},
+			options: Options{PriorityKeys: []string{"name"}},
+			want:    "name: first\nb: 1\n---\na: 1\n",
+		},
+		{
+			name:    "unexpected_type",
+			data:    []interface{}{1.0},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := YAMLStreamData("stream.yaml", tt.data, tt.options)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(file.Content))
+			assert.Equal(t, FormatYAML, file.Format)
+		})
+	}
+}
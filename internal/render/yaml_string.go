@@ -8,38 +8,30 @@ import (
 	yamlcmd "gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/cmd/yaml"
 )
 
-// YAMLStringData will render a string as a YAML file.
-func YAMLStringData(filenameKey string, data string, options Options) error {
+// yamlStringDocument parses a pre-manifested YAML document, ordering the keys
+// according to the priority keys.
+func yamlStringDocument(data string, options Options) (yaml.MapSlice, error) {
 	m := make(map[interface{}]interface{})
 
 	err := yaml.Unmarshal([]byte(data), &m)
 	if err != nil {
-		return fmt.Errorf("unmarshal failed: %w: %w", err, errRenderFailure)
+		return nil, fmt.Errorf("unmarshal failed: %w: %w", err, errRenderFailure)
 	}
 
-	f, filePath, err := openFileForRender(filenameKey, options)
-	if err != nil {
-		return fmt.Errorf("open file failed: %w: %w", err, errRenderFailure)
-	}
-
-	defer f.Close()
+	return yamlcmd.ReorderKeys(m, options.PriorityKeys), nil
+}
 
-	if options.Header != "" {
-		_, err = f.WriteString(options.Header + "\n")
-		if err != nil {
-			return fmt.Errorf("write failed: %w: %w", err, errRenderFailure)
-		}
+// YAMLStringData will render a string as a YAML file.
+func YAMLStringData(filenameKey string, data string, options Options) (*File, error) {
+	ordered, err := yamlStringDocument(data, options)
+	if err != nil {
+		return nil, err
 	}
 
-	ordered := yamlcmd.ReorderKeys(m, options.PriorityKeys)
-	encoder := yaml.NewEncoder(f)
-
-	err = encoder.Encode(ordered)
+	content, err := encodeYAMLDocuments([]interface{}{ordered}, options)
 	if err != nil {
-		return fmt.Errorf("encode failed: %w: %w", err, errRenderFailure)
+		return nil, err
 	}
 
-	fmt.Println(filePath)
-
-	return nil
+	return &File{Path: outputPathForRender(filenameKey, options), Content: content, Format: FormatYAML}, nil
 }
@@ -0,0 +1,169 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	byaml "github.com/braydonk/yaml"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	defaultYAMLIndent    = 2
+	defaultYAMLLineWidth = 80
+)
+
+// YAML quoting styles for string values.
+const (
+	YAMLQuoteStyleSingle = "single"
+	YAMLQuoteStyleDouble = "double"
+)
+
+// YAMLQuoteStyles are the supported YAML quoting styles.
+var YAMLQuoteStyles = []string{YAMLQuoteStyleSingle, YAMLQuoteStyleDouble}
+
+// YAMLStyle controls the formatting of YAML output.
+type YAMLStyle struct {
+	// Indent is the number of spaces used for indentation. Defaults to 2.
+	Indent int
+
+	// LineWidth is the preferred line width, beyond which long strings are folded.
+	// Defaults to 80, use -1 for unlimited.
+	LineWidth int
+
+	// LiteralBlocks will emit multiline strings as literal block scalars, even when
+	// a QuoteStyle is set.
+	LiteralBlocks bool
+
+	// QuoteStyle is the quoting style for string values, one of YAMLQuoteStyleSingle or
+	// YAMLQuoteStyleDouble. By default, strings are only quoted when required.
+	QuoteStyle string
+}
+
+func (s YAMLStyle) indent() int {
+	if s.Indent == 0 {
+		return defaultYAMLIndent
+	}
+
+	return s.Indent
+}
+
+func (s YAMLStyle) lineWidth() int {
+	if s.LineWidth == 0 {
+		return defaultYAMLLineWidth
+	}
+
+	return s.LineWidth
+}
+
+func (s YAMLStyle) isDefault() bool {
+	return s.indent() == defaultYAMLIndent && s.lineWidth() == defaultYAMLLineWidth && !s.LiteralBlocks && s.QuoteStyle == ""
+}
+
+// encodeYAMLDocuments encodes each document into a YAML stream, formatted according
+// to the YAML style.
+func encodeYAMLDocuments(documents []interface{}, options Options) ([]byte, error) {
+	var body bytes.Buffer
+
+	encoder := yaml.NewEncoder(&body)
+
+	for i, document := range documents {
+		err := encoder.Encode(document)
+		if err != nil {
+			return nil, fmt.Errorf("encode failed for document %d: %w: %w", i, err, errRenderFailure)
+		}
+	}
+
+	err := encoder.Close()
+	if err != nil {
+		return nil, fmt.Errorf("encode failed: %w: %w", err, errRenderFailure)
+	}
+
+	return formatYAML(body.Bytes(), options.YAML)
+}
+
+// formatYAML re-encodes a YAML stream using the YAML style. When the style is the default,
+// the stream is returned unchanged.
+func formatYAML(content []byte, style YAMLStyle) ([]byte, error) {
+	if style.isDefault() {
+		return content, nil
+	}
+
+	if style.QuoteStyle != "" && !slices.Contains(YAMLQuoteStyles, style.QuoteStyle) {
+		return nil, fmt.Errorf("unknown YAML quote style %q: %w", style.QuoteStyle, errRenderFailure)
+	}
+
+	if style.Indent < 0 {
+		return nil, fmt.Errorf("invalid YAML indent %d: %w", style.Indent, errRenderFailure)
+	}
+
+	var buf bytes.Buffer
+
+	decoder := byaml.NewDecoder(bytes.NewReader(content))
+	encoder := byaml.NewEncoder(&buf)
+	encoder.SetIndent(style.indent())
+	encoder.SetWidth(style.lineWidth())
+
+	// Match the sequence indentation used by gopkg.in/yaml.v2
+	encoder.SetIndentlessBlockSequence(true)
+
+	for {
+		var node byaml.Node
+
+		err := decoder.Decode(&node)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("unable to format YAML: %w: %w", err, errRenderFailure)
+		}
+
+		applyYAMLStyle(&node, style)
+
+		err = encoder.Encode(&node)
+		if err != nil {
+			return nil, fmt.Errorf("unable to format YAML: %w: %w", err, errRenderFailure)
+		}
+	}
+
+	err := encoder.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to format YAML: %w: %w", err, errRenderFailure)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// applyYAMLStyle recursively applies the scalar styles to all string values.
+func applyYAMLStyle(node *byaml.Node, style YAMLStyle) {
+	switch node.Kind {
+	case byaml.DocumentNode, byaml.SequenceNode:
+		for _, child := range node.Content {
+			applyYAMLStyle(child, style)
+		}
+	case byaml.MappingNode:
+		// Content alternates between keys and values, only values are styled
+		for i := 1; i < len(node.Content); i += 2 {
+			applyYAMLStyle(node.Content[i], style)
+		}
+	case byaml.ScalarNode:
+		if node.ShortTag() != "!!str" {
+			return
+		}
+
+		switch {
+		case style.LiteralBlocks && strings.Contains(node.Value, "\n"):
+			node.Style = byaml.LiteralStyle
+		case style.QuoteStyle == YAMLQuoteStyleSingle:
+			node.Style = byaml.SingleQuotedStyle
+		case style.QuoteStyle == YAMLQuoteStyleDouble:
+			node.Style = byaml.DoubleQuotedStyle
+		}
+	case byaml.AliasNode:
+	}
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLStyle(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]interface{}{
+		"name":   "example",
+		"script": "echo hello\necho world\n",
+		"nested": map[string]interface{}{
+			"count": 1.0,
+			"list":  []interface{}{"a", map[string]interface{}{"b": "c"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			options: Options{PriorityKeys: []string{"script"}},
+			want: `script: |
+  echo hello
+  echo world
+name: example
+nested:
+  count: 1
+  list:
+  - a
+  - b: c
+`,
+		},
+		{
+			name:    "indent",
+			options: Options{YAML: YAMLStyle{Indent: 4}},
+			want: `name: example
+nested:
+    count: 1
+    list:
+    - a
+    - b: c
+script: |
+    echo hello
+    echo world
+`,
+		},
+		{
+			name:    "double_quotes",
+			options: Options{YAML: YAMLStyle{QuoteStyle: YAMLQuoteStyleDouble}},
+			want: `name: "example"
+nested:
+  count: 1
+  list:
+  - "a"
+  - b: "c"
+script: "echo hello\necho world\n"
+`,
+		},
+		{
+			name:    "single_quotes_and_literal_blocks",
+			options: Options{YAML: YAMLStyle{QuoteStyle: YAMLQuoteStyleSingle, LiteralBlocks: true}},
+			want: `name: 'example'
+nested:
+  count: 1
+  list:
+  - 'a'
+  - b: 'c'
+script: |
+  echo hello
+  echo world
+`,
+		},
+		{
+			name:    "unknown_quote_style",
+			options: Options{YAML: YAMLStyle{QuoteStyle: "backtick"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := YAMLMapData("file.yaml", data, tt.options)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(file.Content))
+		})
+	}
+}
+
+func TestYAMLLineWidth(t *testing.T) {
+	t.Parallel()
+
+	long := "the quick brown fox jumps over the lazy dog, the quick brown fox jumps over the lazy dog"
+	data := map[string]interface{}{"long": long}
+
+	file, err := YAMLMapData("file.yaml", data, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, string(file.Content), "\n  ", "long strings should be folded by default")
+
+	file, err = YAMLMapData("file.yaml", data, Options{YAML: YAMLStyle{LineWidth: -1}})
+	require.NoError(t, err)
+	assert.Equal(t, "long: "+long+"\n", string(file.Content))
+}
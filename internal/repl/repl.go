@@ -0,0 +1,257 @@
+// Package repl evaluates Jsonnet interactively, one input at a time, retaining local
+// variables between inputs.
+package repl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	jsonnet "github.com/google/go-jsonnet"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+// Output formats for results.
+const (
+	OutputFormatJSON = "json"
+	OutputFormatYAML = "yaml"
+)
+
+// OutputFormats are the supported output formats for results.
+var OutputFormats = []string{OutputFormatJSON, OutputFormatYAML}
+
+// SnippetName is the file name given to inputs. Relative imports are resolved from the working directory.
+const SnippetName = "<repl>"
+
+var (
+	// ErrIncomplete is returned when an input is not yet a complete expression or local binding,
+	// such as an object which has not been closed, and further lines should be read.
+	ErrIncomplete = errors.New("incomplete input")
+	// ErrQuit is returned when the user asks to leave the session.
+	ErrQuit = errors.New("quit")
+
+	errUnknownCommand = errors.New("unknown command")
+)
+
+// incompleteMessages are fragments of the go-jsonnet parser's messages for input which ends too early.
+var incompleteMessages = []string{"end of file", "Unexpected EOF", "Unterminated String"}
+
+// fieldsSnippet lists the fields of an object, marking hidden fields with ::. The object is
+// bound to an unusual name, so that it does not hide any local of the same name used within it.
+const fieldsSnippet = `
+local __replFieldsValue = (
+%s
+);
+[
+  if std.objectHas(__replFieldsValue, f) then f + ':' else f + '::'
+  for f in std.objectFieldsAll(__replFieldsValue)
+]
+`
+
+// Help describes the commands available in a session.
+const Help = `Enter a Jsonnet expression to evaluate it, or a local binding, such as
+local lib = import 'lib.libsonnet';
+to make it available to later inputs. Incomplete input continues on the next line.
+
+Commands:
+  :fields <expr>  List the fields of an object, marking hidden fields with ::
+  :format [json|yaml]  Show or set the output format
+  :locals         List the local bindings
+  :reset          Remove every local binding
+  :reload         Import files again, picking up any changes
+  :help           Show this help
+  :quit           Leave the session
+`
+
+// Options configures a Session.
+type Options struct {
+	// OutputFormat is the format of results, one of OutputFormats.
+	OutputFormat string
+	// Render configures YAML output.
+	Render render.Options
+}
+
+// Session evaluates inputs, retaining local bindings between them.
+type Session struct {
+	options Options
+	makeVM  func() (*jsonnet.VM, error)
+	vm      *jsonnet.VM
+	// locals are the local binding statements entered so far, in order
+	locals []string
+}
+
+// New returns a Session evaluating inputs with a VM from makeVM. makeVM is called again
+// when files are reloaded, so that changed files are not served from the importer's cache.
+func New(makeVM func() (*jsonnet.VM, error), options Options) (*Session, error) {
+	if !slices.Contains(OutputFormats, options.OutputFormat) {
+		return nil, fmt.Errorf("unknown output format %q", options.OutputFormat)
+	}
+
+	vm, err := makeVM()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{options: options, makeVM: makeVM, vm: vm}, nil
+}
+
+// Execute evaluates a command, a local binding or an expression, writing any result to out.
+// ErrIncomplete is returned when input needs further lines, and ErrQuit when the session should end.
+func (s *Session) Execute(input string, out io.Writer) error {
+	trimmed := strings.TrimSpace(input)
+
+	switch {
+	case trimmed == "":
+		return nil
+	case strings.HasPrefix(trimmed, ":"):
+		return s.command(trimmed, out)
+	case isBinding(trimmed):
+		return s.bind(trimmed)
+	}
+
+	output, err := s.evaluate(trimmed)
+	if err != nil {
+		return err
+	}
+
+	return s.write(output, out)
+}
+
+// isBinding reports whether input is a local binding without a body, such as `local a = 1;`.
+func isBinding(input string) bool {
+	words := strings.Fields(input)
+
+	return len(words) > 0 && words[0] == "local" && strings.HasSuffix(input, ";")
+}
+
+// command runs a command, such as `:fields`.
+func (s *Session) command(input string, out io.Writer) error {
+	name, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case ":help", ":h":
+		_, err := io.WriteString(out, Help)
+		return err
+	case ":quit", ":q":
+		return ErrQuit
+	case ":format":
+		if arg == "" {
+			_, err := fmt.Fprintln(out, s.options.OutputFormat)
+			return err
+		}
+
+		if !slices.Contains(OutputFormats, arg) {
+			return fmt.Errorf("unknown output format %q, expected one of: %s", arg, strings.Join(OutputFormats, ", "))
+		}
+
+		s.options.OutputFormat = arg
+
+		return nil
+	case ":fields":
+		return s.fields(arg, out)
+	case ":locals":
+		for _, local := range s.locals {
+			_, err := fmt.Fprintln(out, local)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case ":reset":
+		s.locals = nil
+		return nil
+	case ":reload":
+		vm, err := s.makeVM()
+		if err != nil {
+			return err
+		}
+
+		s.vm = vm
+
+		return nil
+	default:
+		return fmt.Errorf("%w %s, use :help to list commands", errUnknownCommand, name)
+	}
+}
+
+// bind checks a local binding, then retains it for later inputs. The bound values are not
+// evaluated until they are used, as elsewhere in Jsonnet.
+func (s *Session) bind(input string) error {
+	_, err := s.evaluate(input + "\nnull")
+	if err != nil {
+		return err
+	}
+
+	s.locals = append(s.locals, input)
+
+	return nil
+}
+
+// fields writes the fields of the object given by expr, one per line.
+func (s *Session) fields(expr string, out io.Writer) error {
+	if expr == "" {
+		return errors.New(":fields requires an expression")
+	}
+
+	output, err := s.evaluate(fmt.Sprintf(fieldsSnippet, expr))
+	if err != nil {
+		return err
+	}
+
+	var fields []string
+
+	err = json.Unmarshal([]byte(output), &fields)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal fields: %w", err)
+	}
+
+	for _, f := range fields {
+		_, err = fmt.Fprintln(out, f)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// evaluate evaluates code following the local bindings, returning the JSON output of the VM.
+func (s *Session) evaluate(code string) (string, error) {
+	snippet := strings.Join(append(slices.Clone(s.locals), code), "\n")
+
+	_, err := jsonnet.SnippetToAST(SnippetName, snippet)
+	if err != nil && isIncomplete(err) {
+		return "", ErrIncomplete
+	}
+
+	return s.vm.EvaluateAnonymousSnippet(SnippetName, snippet)
+}
+
+// isIncomplete reports whether a parse error was caused by input ending too early.
+func isIncomplete(err error) bool {
+	for _, message := range incompleteMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// write converts the JSON output of the VM to the output format and writes it to out.
+func (s *Session) write(output string, out io.Writer) error {
+	content, err := render.FormatValue(output, s.options.OutputFormat, s.options.Render)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(content)
+
+	return err
+}
@@ -0,0 +1,108 @@
+package repl
+
+import (
+	"bytes"
+	"testing"
+
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionExecute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		inputs     []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			name:       "expression",
+			inputs:     []string{"{ a: [1, 2] }"},
+			wantOutput: "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n",
+		},
+		{
+			name:       "locals_are_retained",
+			inputs:     []string{"local a = 1;", "local f(x) = x + a;", "f(2)"},
+			wantOutput: "3\n",
+		},
+		{
+			name:       "locals_are_lazy",
+			inputs:     []string{"local a = error 'unused';", "local b = 2;", "b"},
+			wantOutput: "2\n",
+		},
+		{
+			name:       "list_locals",
+			inputs:     []string{"local a = 1;", ":locals"},
+			wantOutput: "local a = 1;\n",
+		},
+		{
+			name:    "reset",
+			inputs:  []string{"local a = 1;", ":reset", "a"},
+			wantErr: assert.AnError,
+		},
+		{
+			name:       "fields",
+			inputs:     []string{"local __replFieldsValue = 1;", ":fields { b: 1, a:: __replFieldsValue }"},
+			wantOutput: "a::\nb:\n",
+		},
+		{
+			name:       "yaml",
+			inputs:     []string{":format yaml", "{ a: [1, 2] }"},
+			wantOutput: "a:\n- 1\n- 2\n",
+		},
+		{
+			name:    "unknown_format",
+			inputs:  []string{":format xml"},
+			wantErr: assert.AnError,
+		},
+		{
+			name:    "incomplete",
+			inputs:  []string{"{ a:"},
+			wantErr: ErrIncomplete,
+		},
+		{
+			name:    "incomplete_local",
+			inputs:  []string{"local a = {"},
+			wantErr: ErrIncomplete,
+		},
+		{
+			name:    "unknown_variable",
+			inputs:  []string{"local a = b;"},
+			wantErr: assert.AnError,
+		},
+		{
+			name:    "quit",
+			inputs:  []string{":quit"},
+			wantErr: ErrQuit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			session, err := New(func() (*jsonnet.VM, error) { return jsonnet.MakeVM(), nil }, Options{OutputFormat: OutputFormatJSON})
+			require.NoError(t, err)
+
+			var out bytes.Buffer
+
+			for _, input := range tt.inputs {
+				err = session.Execute(input, &out)
+			}
+
+			switch tt.wantErr {
+			case nil:
+				require.NoError(t, err)
+			case assert.AnError:
+				require.Error(t, err)
+			default:
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+
+			assert.Equal(t, tt.wantOutput, out.String())
+		})
+	}
+}
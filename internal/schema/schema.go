@@ -0,0 +1,270 @@
+// Package schema validates rendered files against JSON Schemas, selected by matching the
+// path of each file against globs.
+package schema
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
+	byaml "github.com/braydonk/yaml"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+var (
+	errInvalidMapping  = errors.New("invalid schema mapping")
+	errUnsupportedType = errors.New("unsupported file type")
+)
+
+// Mapping selects the schema used to validate the files whose paths match a glob.
+type Mapping struct {
+	// Pattern is a glob matched against the path of each file, relative to the output directory.
+	// Supports ** to match any number of directories.
+	Pattern string
+	// Schema is the path of the JSON Schema file.
+	Schema string
+}
+
+// ParseMappings parses mappings in the form glob=schema.
+func ParseMappings(values []string) ([]Mapping, error) {
+	mappings := make([]Mapping, 0, len(values))
+
+	for _, value := range values {
+		pattern, schemaPath, found := strings.Cut(value, "=")
+		if !found || pattern == "" || schemaPath == "" {
+			return nil, fmt.Errorf("%q: expected glob=schema: %w", value, errInvalidMapping)
+		}
+
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("%q: invalid glob %q: %w", value, pattern, errInvalidMapping)
+		}
+
+		mappings = append(mappings, Mapping{Pattern: pattern, Schema: schemaPath})
+	}
+
+	return mappings, nil
+}
+
+// Violation is a value which does not conform to a schema.
+type Violation struct {
+	// Path is the path of the file containing the value.
+	Path string
+	// Document is the index of the document containing the value, for YAML streams with more than one document, or -1.
+	Document int
+	// Pointer is the JSON pointer to the value within the document.
+	Pointer string
+	// Schema is the path of the schema.
+	Schema string
+	// Message describes the violation.
+	Message string
+}
+
+func (v Violation) String() string {
+	location := v.Path
+	if v.Document >= 0 {
+		location = fmt.Sprintf("%s (document %d)", v.Path, v.Document)
+	}
+
+	return fmt.Sprintf("%s: #%s: %s (%s)", location, v.Pointer, v.Message, v.Schema)
+}
+
+type compiledMapping struct {
+	Mapping
+	schema *jsonschema.Schema
+}
+
+// Validator validates files against the schemas of the mappings their paths match.
+type Validator struct {
+	mappings []compiledMapping
+}
+
+// New compiles the schema of each mapping, returning a Validator for the mappings.
+func New(mappings []Mapping) (*Validator, error) {
+	compiler := jsonschema.NewCompiler()
+	compiled := make([]compiledMapping, 0, len(mappings))
+
+	for _, mapping := range mappings {
+		absPath, err := filepath.Abs(mapping.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve schema %s: %w", mapping.Schema, err)
+		}
+
+		schema, err := compiler.Compile(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile schema %s: %w", mapping.Schema, err)
+		}
+
+		compiled = append(compiled, compiledMapping{Mapping: mapping, schema: schema})
+	}
+
+	return &Validator{mappings: compiled}, nil
+}
+
+// Validate validates the content of the file at filePath against the schema of every mapping whose glob
+// matches name, the path of the file relative to the output directory. format is the render format of
+// the content, and only for plain files is the format determined by the extension of name. Files which
+// match no mapping are not validated.
+func (v *Validator) Validate(name string, filePath string, format string, content []byte) ([]Violation, error) {
+	var matched []compiledMapping
+
+	for _, mapping := range v.mappings {
+		ok, err := doublestar.Match(mapping.Pattern, filepath.ToSlash(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", mapping.Pattern, err)
+		}
+
+		if ok {
+			matched = append(matched, mapping)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	documents, err := decodeDocuments(name, format, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s for schema validation: %w", filePath, err)
+	}
+
+	var violations []Violation
+
+	for _, mapping := range matched {
+		for i, document := range documents {
+			index := -1
+			if len(documents) > 1 {
+				index = i
+			}
+
+			err := mapping.schema.Validate(document)
+			if err == nil {
+				continue
+			}
+
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) {
+				return nil, fmt.Errorf("failed to validate %s against %s: %w", filePath, mapping.Schema, err)
+			}
+
+			for _, leaf := range leaves(validationErr) {
+				violations = append(violations, Violation{
+					Path:     filePath,
+					Document: index,
+					Pointer:  leaf.InstanceLocation,
+					Schema:   mapping.Schema,
+					Message:  leaf.Message,
+				})
+			}
+		}
+	}
+
+	slices.SortStableFunc(violations, func(a, b Violation) int {
+		return cmp.Or(cmp.Compare(a.Document, b.Document), strings.Compare(a.Pointer, b.Pointer))
+	})
+
+	return violations, nil
+}
+
+// leaves returns the errors which caused a validation error, excluding the errors which
+// only summarise their causes.
+func leaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var r []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		r = append(r, leaves(cause)...)
+	}
+
+	return r
+}
+
+// extensionFormats maps the extensions of plain files to the format of their content.
+var extensionFormats = map[string]string{
+	".json": render.FormatJSON,
+	".yaml": render.FormatYAML,
+	".yml":  render.FormatYAML,
+	".toml": render.FormatTOML,
+}
+
+// decodeDocuments decodes the documents in the content of a JSON, YAML or TOML file into the values
+// produced by decoding JSON, as expected by the validator.
+func decodeDocuments(name string, format string, content []byte) ([]interface{}, error) {
+	if format == render.FormatPlain {
+		var ok bool
+
+		format, ok = extensionFormats[path.Ext(name)]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", path.Ext(name), errUnsupportedType)
+		}
+	}
+
+	var documents []interface{}
+
+	switch format {
+	case render.FormatJSON:
+		documents = []interface{}{json.RawMessage(content)}
+	case render.FormatYAML:
+		decoder := byaml.NewDecoder(bytes.NewReader(content))
+
+		for {
+			var document interface{}
+
+			err := decoder.Decode(&document)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				return nil, err
+			}
+
+			documents = append(documents, document)
+		}
+	case render.FormatTOML:
+		document := map[string]interface{}{}
+
+		_, err := toml.Decode(string(content), &document)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = []interface{}{document}
+	default:
+		return nil, fmt.Errorf("%s: %w", format, errUnsupportedType)
+	}
+
+	// Values are converted to their JSON equivalents, such as timestamps to strings
+	for i, document := range documents {
+		content, err := json.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+
+		var value interface{}
+
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		documents[i] = value
+	}
+
+	return documents, nil
+}
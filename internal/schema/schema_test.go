@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/gitlab-com/gl-infra/jsonnet-tool/internal/render"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": { "type": "string" },
+    "port": { "type": "integer", "maximum": 65535 }
+  }
+}`
+
+func TestParseMappings(t *testing.T) {
+	t.Parallel()
+
+	mappings, err := ParseMappings([]string{"**/*.yaml=schema.json", "a=b=c.json"})
+	require.NoError(t, err)
+	assert.Equal(t, []Mapping{{Pattern: "**/*.yaml", Schema: "schema.json"}, {Pattern: "a", Schema: "b=c.json"}}, mappings)
+
+	for _, value := range []string{"schema.json", "=schema.json", "*.yaml=", "[.yaml=schema.json"} {
+		_, err := ParseMappings([]string{value})
+		require.ErrorIs(t, err, errInvalidMapping, value)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testSchema), 0644))
+
+	validator, err := New([]Mapping{{Pattern: "services/**", Schema: schemaPath}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		file    string
+		format  string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "valid_json",
+			file:    "services/a.json",
+			format:  render.FormatJSON,
+			content: `{"name": "a", "port": 80}`,
+		},
+		{
+			name:    "invalid_json",
+			file:    "services/a.json",
+			format:  render.FormatJSON,
+			content: `{"port": 65536}`,
+			want: []string{
+				"out/file: #: missing properties: 'name' (" + schemaPath + ")",
+				"out/file: #/port: must be <= 65535 but found 65536 (" + schemaPath + ")",
+			},
+		},
+		{
+			name:    "yaml_stream",
+			file:    "services/nested/a.yaml",
+			format:  render.FormatYAML,
+			content: "name: a\n---\nname: 1\n",
+			want:    []string{"out/file (document 1): #/name: expected string, but got number (" + schemaPath + ")"},
+		},
+		{
+			name:    "toml",
+			file:    "services/a.toml",
+			format:  render.FormatTOML,
+			content: "name = \"a\"\nport = 1.5\n",
+			want:    []string{"out/file: #/port: expected integer, but got number (" + schemaPath + ")"},
+		},
+		{
+			name:    "not_matched",
+			file:    "other/a.json",
+			format:  render.FormatJSON,
+			content: `{}`,
+		},
+		{
+			name:    "format_overrides_extension",
+			file:    "services/a.rules",
+			format:  render.FormatYAML,
+			content: "name: 1\n",
+			want:    []string{"out/file: #/name: expected string, but got number (" + schemaPath + ")"},
+		},
+		{
+			name:    "plain_by_extension",
+			file:    "services/a.yaml",
+			format:  render.FormatPlain,
+			content: "name: 1\n",
+			want:    []string{"out/file: #/name: expected string, but got number (" + schemaPath + ")"},
+		},
+		{
+			name:    "unsupported_type",
+			file:    "services/a.txt",
+			format:  render.FormatPlain,
+			content: "text",
+			wantErr: true,
+		},
+		{
+			name:    "undecodable",
+			file:    "services/a.json",
+			format:  render.FormatJSON,
+			content: "{",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			violations, err := validator.Validate(tt.file, "out/file", tt.format, []byte(tt.content))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			var got []string
+			for _, violation := range violations {
+				got = append(got, violation.String())
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package watch notifies of changes to a set of files.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher watches a set of files for changes. The directories containing the files are
+// watched, rather than the files themselves, so that files replaced by editors, which
+// commonly write a new file and rename it into place, continue to be watched.
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+	files    map[string]struct{}
+	dirs     map[string]struct{}
+}
+
+// New returns a Watcher. Changes are reported once no further changes have been seen for
+// the debounce period, so that a burst of writes is reported as a single change.
+func New(debounce time.Duration) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	return &Watcher{
+		watcher:  watcher,
+		debounce: debounce,
+		files:    map[string]struct{}{},
+		dirs:     map[string]struct{}{},
+	}, nil
+}
+
+// Close stops watching all files.
+func (w *Watcher) Close() error {
+	err := w.watcher.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close watcher: %w", err)
+	}
+
+	return nil
+}
+
+// SetFiles replaces the set of watched files.
+func (w *Watcher) SetFiles(files []string) error {
+	newFiles := map[string]struct{}{}
+	newDirs := map[string]struct{}{}
+
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path: %w", err)
+		}
+
+		newFiles[abs] = struct{}{}
+		newDirs[filepath.Dir(abs)] = struct{}{}
+	}
+
+	for dir := range newDirs {
+		if _, ok := w.dirs[dir]; ok {
+			continue
+		}
+
+		err := w.watcher.Add(dir)
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	for dir := range w.dirs {
+		if _, ok := newDirs[dir]; ok {
+			continue
+		}
+
+		// The directory may already have been removed, in which case it is no longer watched
+		_ = w.watcher.Remove(dir)
+	}
+
+	w.files = newFiles
+	w.dirs = newDirs
+
+	return nil
+}
+
+// Next blocks until one or more of the watched files change, returning the absolute paths of
+// the changed files in sorted order. Returns an error if ctx is cancelled or watching fails.
+func (w *Watcher) Next(ctx context.Context) ([]string, error) {
+	changed := map[string]struct{}{}
+
+	// The debounce timer only runs once a change has been seen
+	var timer <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("watch stopped: %w", ctx.Err())
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil, fmt.Errorf("watcher closed: %w", context.Canceled)
+			}
+
+			return nil, fmt.Errorf("watch failed: %w", err)
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil, fmt.Errorf("watcher closed: %w", context.Canceled)
+			}
+
+			if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) &&
+				!event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+
+			name := filepath.Clean(event.Name)
+			if _, ok := w.files[name]; !ok {
+				continue
+			}
+
+			changed[name] = struct{}{}
+			timer = time.After(w.debounce)
+		case <-timer:
+			paths := make([]string, 0, len(changed))
+			for p := range changed {
+				paths = append(paths, p)
+			}
+
+			slices.Sort(paths)
+
+			return paths, nil
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherNext(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "watched.libsonnet")
+	unwatched := filepath.Join(dir, "unwatched.libsonnet")
+
+	require.NoError(t, os.WriteFile(watched, []byte("{}"), 0o600))
+
+	w, err := New(50 * time.Millisecond)
+	require.NoError(t, err)
+
+	defer w.Close()
+
+	require.NoError(t, w.SetFiles([]string{watched}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		_ = os.WriteFile(unwatched, []byte("{}"), 0o600)
+
+		// Replace the watched file, as many editors do
+		tmp := watched + ".tmp"
+		_ = os.WriteFile(tmp, []byte("{ a: 1 }"), 0o600)
+		_ = os.Rename(tmp, watched)
+	}()
+
+	changed, err := w.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{watched}, changed)
+
+	cancel()
+
+	_, err = w.Next(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}